@@ -14,14 +14,36 @@ func parseAlternation(state *State, into reflect.Type, tag reflect.StructTag) (i
 	if name == "" {
 		panic(fmt.Sprintf("cannot parse alternative %+v that has no name (either annotated as tag where used as a field, or on `Choice` field.)", into))
 	}
+	start := state.Position
+	lexical := isLexical(into)
+	if lexical {
+		// A lexical rule is a token, so the trivia before it is skipped as for a leaf.
+		skipTrivia(state)
+		start = state.Position
+	}
 	for i := 1; i < into.NumField(); i++ {
-		result, err := parseIntoTypeRaw(state, into.Field(i).Type, into.Field(i).Tag)
+		if !isGrammarField(into, i) {
+			continue
+		}
+		var result interface{}
+		var err error
+		untrimmed := lexical || into.Field(i).Tag.Get("trivia") == "-"
+		withoutTrivia(state, untrimmed, func() {
+			// A failed alternative lets the next one be tried, rather than calling Failed.
+			result, err = parseIntoTypeMemo(state, into.Field(i).Type, into.Field(i).Tag, false)
+		})
+		state.recordCoverage(into, i, result, err)
 		if err == nil {
 			value := reflect.New(into).Elem()
 			value.Field(0).Set(reflect.ValueOf(Choice{into.Field(i).Name, i}))
 			value.Field(i).Set(reflect.ValueOf(result))
+			// Trivia before an alternative parsed without skipping trivia is part of it.
+			if end := afterTrivia(state, start); end <= state.Position && !untrimmed {
+				start = end
+			}
+			fillSpans(state, value, start)
 			return value.Interface(), nil
 		}
 	}
-	return nil, fmt.Errorf("Expected %s at %s", into.Field(0).Tag.Get("name"), state.Location())
+	return nil, state.ErrorAt(afterTrivia(state, start), "Expected %s", name)
 }
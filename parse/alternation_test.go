@@ -0,0 +1,110 @@
+package parse
+
+import (
+	"fmt"
+	"testing"
+)
+
+type alternationGroup struct {
+	Open  Open
+	Items []alternationItem
+}
+
+type alternationCall struct {
+	Open  Open
+	Word  Regex `regex:"[a-z]+"`
+	Close Close
+}
+
+type alternationItem struct {
+	Choice `name:"item"`
+	Close  Close
+	Word   Regex `regex:"[a-z]+"`
+}
+
+func TestAlternationFailed(t *testing.T) {
+	// The Close alternative fails on the word, and the next alternative is tried rather
+	// than reporting an unmatched '('.
+	var result alternationGroup
+	if err := Parse("(a)", &result); err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	if len(result.Items) != 2 || result.Items[0].Choice.Choice != "Word" || result.Items[1].Choice.Choice != "Close" {
+		t.Errorf("unexpected items %+v", result.Items)
+	}
+	// A Close field of a sequence still reports the '(' it was expected to match.
+	err := Parse("(a", &alternationCall{})
+	if err == nil || err.Error() != "expected `)` to match `(` opened at 1:1 at 1:3" {
+		t.Errorf("expected an unmatched '(' error but got %v", err)
+	}
+}
+
+type alternationMaybeClose struct {
+	Open  Open
+	Item  *alternationCloseOrBang
+	Close Close
+}
+
+type alternationCloseOrBang struct {
+	Choice `name:"close or bang"`
+	Close  Close
+	Bang   Literal `parse:"!"`
+}
+
+type alternationEven struct {
+	Digits Regex `regex:"[0-9]+"`
+}
+
+func (e alternationEven) Verify() error {
+	if (e.Digits.Contents[len(e.Digits.Contents)-1]-'0')%2 != 0 {
+		return fmt.Errorf("odd number")
+	}
+	return nil
+}
+
+type alternationNumber struct {
+	Choice `name:"number"`
+	Even   alternationEven
+	Any    Regex `regex:"[0-9]+"`
+}
+
+type alternationMaybeEven struct {
+	Maybe *alternationEvenOrBang
+	Even  alternationEven
+}
+
+type alternationEvenOrBang struct {
+	Choice `name:"even or bang"`
+	Even   alternationEven
+	Bang   Literal `parse:"!"`
+}
+
+type alternationNumberThenEven struct {
+	Number alternationNumber
+	Space  Literal `parse:" "`
+	Even   alternationEven
+}
+
+func TestAlternationCached(t *testing.T) {
+	// The Close alternative fails at the end of the input, and isn't remembered, so the
+	// Close field at the same position still reports the unmatched '('.
+	err := Parse("(", &alternationMaybeClose{})
+	if err == nil || err.Error() != "expected `)` to match `(` opened at 1:1 at 1:2" {
+		t.Errorf("expected an unmatched '(' error but got %v", err)
+	}
+	// An alternative is checked with Verify, like a field of the same type.
+	var result alternationNumberThenEven
+	if err := Parse("13 24", &result); err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	if result.Number.Choice.Choice != "Any" || string(result.Even.Digits.Contents) != "24" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if err := Parse("12 23", &result); err == nil || err.Error() != "odd number" {
+		t.Errorf("expected the field to be rejected by Verify but got %v", err)
+	}
+	// The Even alternative's failed Verify is remembered, and reused for the field.
+	if err := Parse("3", &alternationMaybeEven{}); err == nil || err.Error() != "odd number" {
+		t.Errorf("expected the cached Verify error but got %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package parse
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Class is a run of characters from a character class, configured with tags:
+//
+//	class:"a-zA-Z0-9_"     the characters and ranges allowed; '\' makes the next character literal.
+//	class:"^\n"            a leading '^' allows every character except those listed.
+//	class:"L|Nd|_"         parts separated by '|'; a part that names a Unicode category, script
+//	                       or property is that table, and any other part is a set of characters.
+//	class:"\\p{L}\\p{Nd}_" a table can also be written \p{Name} (or \pL) among the characters.
+//	min:"0" max:"3"        the number of characters allowed. Default at least 1.
+//
+// A part can't be empty, so a literal '|' is written "\\|", and a letter that would name a table
+// on its own, such as "L", is written "\\L".
+//
+// The longest run is taken, up to max. Classes are compiled once for each tag.
+type Class struct {
+	Contents []byte
+	Span     Span
+}
+
+func (c *Class) ParseInto(state *State, tag reflect.StructTag) error {
+	class := classOf(tag)
+	rest := state.Rest()
+	size := 0
+	count := 0
+	for size < len(rest) && (class.max < 0 || count < class.max) {
+		r, width := utf8.DecodeRune(rest[size:])
+		if !class.matches(r) {
+			break
+		}
+		size += width
+		count++
+	}
+	if count < class.min {
+		return state.ErrorAt(state.Position+size, "Expected character in class %q", class.source)
+	}
+	c.Contents = rest[:size]
+	c.Span = state.Advance(size)
+	return nil
+}
+
+// A characterClass is a compiled class tag.
+type characterClass struct {
+	source   string
+	negated  bool
+	ranges   [][2]rune
+	tables   []*unicode.RangeTable
+	min, max int
+}
+
+// characterClasses caches compiled classes by their tag, and by their source.
+var characterClasses sync.Map
+
+// classOf compiles the class described by a tag, or finds it already compiled.
+func classOf(tag reflect.StructTag) *characterClass {
+	if class, ok := characterClasses.Load(tag); ok {
+		return class.(*characterClass)
+	}
+	source, ok := tag.Lookup("class")
+	if !ok {
+		panic(fmt.Sprintf("parse.Class given no 'class' tag: %q", tag))
+	}
+	class := *compileClass(source)
+	if min, ok := tag.Lookup("min"); ok {
+		class.min = classCount(min, tag)
+	}
+	if max, ok := tag.Lookup("max"); ok {
+		class.max = classCount(max, tag)
+	}
+	if class.max >= 0 && class.min > class.max {
+		panic(fmt.Sprintf("parse.Class given 'min' greater than 'max': %q", tag))
+	}
+	characterClasses.Store(tag, &class)
+	return &class
+}
+
+// compileClass compiles a class written in the syntax of the 'class' tag.
+func compileClass(source string) *characterClass {
+	if class, ok := characterClasses.Load(source); ok {
+		return class.(*characterClass)
+	}
+	class := &characterClass{source: source, min: 1, max: -1}
+	rest := source
+	if strings.HasPrefix(rest, "^") {
+		class.negated = true
+		rest = rest[1:]
+	}
+	for _, part := range splitClass(rest) {
+		if part == "" && rest != "" {
+			panic(fmt.Sprintf("class has an empty part; write '|' as '\\|': %q", source))
+		}
+		if table := unicodeTable(part); table != nil {
+			class.tables = append(class.tables, table)
+			continue
+		}
+		ranges, tables := classRanges(part, source)
+		class.ranges = append(class.ranges, ranges...)
+		class.tables = append(class.tables, tables...)
+	}
+	characterClasses.Store(source, class)
+	return class
+}
+
+func classCount(text string, tag reflect.StructTag) int {
+	count, err := strconv.Atoi(text)
+	if err != nil || count < 0 {
+		panic(fmt.Sprintf("parse.Class given illegal count: %q", tag))
+	}
+	return count
+}
+
+// splitClass splits a class at each '|' that isn't escaped.
+func splitClass(source string) []string {
+	parts := []string{}
+	start := 0
+	for i := 0; i < len(source); i++ {
+		switch source[i] {
+		case '\\':
+			i++
+		case '|':
+			parts = append(parts, source[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, source[start:])
+}
+
+// unicodeTable finds the category, script or property with the given name.
+func unicodeTable(name string) *unicode.RangeTable {
+	if table, ok := unicode.Categories[name]; ok {
+		return table
+	}
+	if table, ok := unicode.Scripts[name]; ok {
+		return table
+	}
+	return unicode.Properties[name]
+}
+
+// classRanges reads the characters, ranges and \p tables of one part of a class.
+// A '-' at either end of the part, or next to a table, is literal.
+func classRanges(text string, source string) ([][2]rune, []*unicode.RangeTable) {
+	runes := []rune{}
+	literal := []bool{}
+	// isTable marks where a table was written, which separates the characters around it.
+	isTable := []bool{}
+	tables := []*unicode.RangeTable{}
+	for i := 0; i < len(text); {
+		r, width := utf8.DecodeRuneInString(text[i:])
+		escaped := r == '\\'
+		if escaped {
+			if i+width >= len(text) {
+				panic(fmt.Sprintf("class has trailing '\\': %q", source))
+			}
+			i += width
+			r, width = utf8.DecodeRuneInString(text[i:])
+		}
+		if escaped && r == 'p' {
+			name, size := tableName(text[i+width:], source)
+			table := unicodeTable(name)
+			if table == nil {
+				panic(fmt.Sprintf("class names unknown Unicode table %q: %q", name, source))
+			}
+			tables = append(tables, table)
+			runes = append(runes, 0)
+			literal = append(literal, true)
+			isTable = append(isTable, true)
+			i += width + size
+			continue
+		}
+		runes = append(runes, r)
+		literal = append(literal, escaped || r != '-')
+		isTable = append(isTable, false)
+		i += width
+	}
+	ranges := [][2]rune{}
+	for i := 0; i < len(runes); i++ {
+		if isTable[i] {
+			continue
+		}
+		if i+2 < len(runes) && !literal[i+1] && !isTable[i+2] {
+			if runes[i] > runes[i+2] {
+				panic(fmt.Sprintf("class has backwards range %q-%q: %q", runes[i], runes[i+2], source))
+			}
+			ranges = append(ranges, [2]rune{runes[i], runes[i+2]})
+			i += 2
+			continue
+		}
+		ranges = append(ranges, [2]rune{runes[i], runes[i]})
+	}
+	return ranges, tables
+}
+
+// tableName reads the name after \p, either one letter or a name in braces.
+func tableName(text string, source string) (string, int) {
+	if strings.HasPrefix(text, "{") {
+		end := strings.IndexByte(text, '}')
+		if end < 0 {
+			panic(fmt.Sprintf("class has unclosed '\\p{': %q", source))
+		}
+		return text[1:end], end + 1
+	}
+	r, width := utf8.DecodeRuneInString(text)
+	if width == 0 {
+		panic(fmt.Sprintf("class has '\\p' without a name: %q", source))
+	}
+	return string(r), width
+}
+
+func (c *characterClass) matches(r rune) bool {
+	for _, each := range c.ranges {
+		if each[0] <= r && r <= each[1] {
+			return !c.negated
+		}
+	}
+	for _, table := range c.tables {
+		if unicode.Is(table, r) {
+			return !c.negated
+		}
+	}
+	return c.negated
+}
@@ -0,0 +1,83 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+type classAssignment struct {
+	Name   Class   `class:"L|_" min:"1"`
+	Rest   Class   `class:"\\pL\\p{Nd}|_" min:"0"`
+	Equals Literal `parse:"="`
+	Digits Class   `class:"0-9" max:"3"`
+	More   Class   `class:"0-9\\-" min:"0"`
+	Line   Class   `class:"^\n" min:"0"`
+}
+
+func TestClass(t *testing.T) {
+	assignment := classAssignment{}
+	if err := Parse("Größe_2=12345-6 rest of line\nnext", &assignment); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got := []string{
+		string(assignment.Name.Contents),
+		string(assignment.Rest.Contents),
+		string(assignment.Digits.Contents),
+		string(assignment.More.Contents),
+		string(assignment.Line.Contents),
+	}
+	want := []string{"Größe_", "2", "123", "45-6", " rest of line"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %q but got %q", want, got)
+	}
+	if assignment.Line.Span.End.Line != 1 {
+		t.Errorf("expected the line to stop before the newline")
+	}
+
+	err := Parse("x=y", &classAssignment{})
+	if err == nil || err.Error() != `Expected character in class "0-9" at 1:3` {
+		t.Errorf("expected a class error but got %v", err)
+	}
+
+	graph := GraphOf(&classAssignment{})
+	if !nullable(graph.Root.Field(1).Type, graph.Root.Field(1).Tag, nil) || nullable(graph.Root.Field(0).Type, graph.Root.Field(0).Tag, nil) {
+		t.Errorf("expected only classes with min:\"0\" to be nullable")
+	}
+}
+
+type classLetters struct {
+	Lower  Class `class:"Ll|\\|"`
+	Letter Class `class:"\\L"`
+}
+
+type classEmptyPart struct {
+	Letters Class `class:"Lu|"`
+}
+
+type classBackwards struct {
+	Digits Class `class:"0-9" min:"3" max:"2"`
+}
+
+type classUnknown struct {
+	Letters Class `class:"\\p{Letters}"`
+}
+
+func TestClassSyntax(t *testing.T) {
+	var letters classLetters
+	if err := Parse("aé|bL", &letters); err != nil || string(letters.Lower.Contents) != "aé|b" || string(letters.Letter.Contents) != "L" {
+		t.Errorf("expected lowercase letters and '|', then 'L', but got %q and %q (%v)", letters.Lower.Contents, letters.Letter.Contents, err)
+	}
+	if err := Parse("aM", &letters); err == nil {
+		t.Errorf("expected \\L to match only the letter L")
+	}
+	for _, target := range []interface{}{&classBackwards{}, &classUnknown{}, &classEmptyPart{}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected %T to panic", target)
+				}
+			}()
+			Parse("123", target)
+		}()
+	}
+}
@@ -1,16 +1,22 @@
 package parse
 
 import (
+	"bytes"
 	"fmt"
 	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Literal is annotated with parse:"<literal>"
+// With the tag case:"fold" it matches with simple Unicode case folding, and Contents holds
+// the text as written in the source.
 type Literal struct {
 	Contents []byte
-	Location string
+	Span     Span
 }
 
 func (l *Literal) ParseInto(state *State, tag reflect.StructTag) error {
@@ -18,67 +24,110 @@ func (l *Literal) ParseInto(state *State, tag reflect.StructTag) error {
 	if literal == "" {
 		panic(fmt.Sprintf("parse.Literal given illegal no 'parse' tag: %q", tag))
 	}
-	for i := 0; i < len(literal); i++ {
-		if i+state.Position >= len(state.Source) || state.Source[i+state.Position] != literal[i] {
-			return fmt.Errorf("Expected %q at %s", literal, state.Location())
-		}
+	size, ok := matchLiteral(state.Rest(), literal, tag)
+	if !ok {
+		return state.Errorf("Expected %q", literal)
+	}
+	if tag.Get("case") == "fold" {
+		l.Contents = state.Rest()[:size]
+	} else {
+		l.Contents = []byte(literal)
 	}
-	l.Contents = []byte(tag.Get("parse"))
-	l.Location = state.Location()
-	state.Position += len(literal)
+	l.Span = state.Advance(size)
 	return nil
 }
 
+// matchLiteral finds the length of the literal at the start of text, folding case when
+// tagged with case:"fold".
+func matchLiteral(text []byte, literal string, tag reflect.StructTag) (int, bool) {
+	switch tag.Get("case") {
+	case "":
+		return len(literal), bytes.HasPrefix(text, []byte(literal))
+	case "fold":
+	default:
+		panic(fmt.Sprintf("literal given illegal 'case' tag: %q", tag))
+	}
+	size := 0
+	for _, want := range literal {
+		if size >= len(text) {
+			return 0, false
+		}
+		got, width := utf8.DecodeRune(text[size:])
+		if foldRune(got) != foldRune(want) {
+			return 0, false
+		}
+		size += width
+	}
+	return size, true
+}
+
+// foldCase maps the text to a canonical spelling under simple Unicode case folding, so that
+// two texts fold to the same spelling exactly when strings.EqualFold reports them equal.
+func foldCase(text string) string {
+	return strings.Map(foldRune, text)
+}
+
+// foldRune maps a character to the smallest character it folds to.
+func foldRune(r rune) rune {
+	smallest := r
+	for other := unicode.SimpleFold(r); other != r; other = unicode.SimpleFold(other) {
+		if other < smallest {
+			smallest = other
+		}
+	}
+	return smallest
+}
+
 type Number struct {
-	Number   float64
-	Location string
+	Number float64
+	Span   Span
 }
 
 // Matches 4e6, -4.e7, .6E20, -0e0
 // Doesn't match .e7, for example.
-var numberRegex = regexp.MustCompile(`-?[0-9]+\.?[0-9]*([eE]-?[0-9]+)?|-?[0-9]*\.?[0-9]+([eE]-?[0-9]+)?`)
+var numberRegex = regexp.MustCompile(`^(?:-?[0-9]+\.?[0-9]*([eE]-?[0-9]+)?|-?[0-9]*\.?[0-9]+([eE]-?[0-9]+)?)`)
 
 func (n *Number) ParseInto(state *State, tag reflect.StructTag) error {
 	matched := numberRegex.Find(state.Rest())
 	if matched == nil {
-		return fmt.Errorf("expected number at %s", state.Location())
+		return state.Errorf("expected number")
 	}
 	number, err := strconv.ParseFloat(string(matched), 64)
 	if err != nil {
-		return fmt.Errorf("expected number at %s but %s", state.Location(), err.Error())
+		return state.Errorf("expected number but %s", err.Error())
 	}
 	n.Number = number
-	n.Location = state.Location()
-	state.Position += len(matched)
+	n.Span = state.Advance(len(matched))
 	return nil
 }
 
+// Location records the current position without consuming anything.
 type Location struct {
-	Location string
+	Position Position
 }
 
 func (l *Location) ParseInto(state *State, tag reflect.StructTag) error {
-	l.Location = state.Location()
+	l.Position = state.Location()
 	return nil
 }
 
 type Regex struct {
 	Contents []byte
-	Location string
+	Span     Span
 }
 
 func (r *Regex) ParseInto(state *State, tag reflect.StructTag) error {
 	regex := regexp.MustCompile(tag.Get("regex"))
 	matched := regex.Find(state.Rest())
 	if matched == nil {
-		return fmt.Errorf("expected string to match regex %q at %s", tag.Get("regex"), state.Location())
+		return state.Errorf("expected string to match regex %q", tag.Get("regex"))
 	}
 	if len(matched) == 0 || &matched[0] == &state.Source[state.Position] {
 		r.Contents = matched
-		state.Position += len(matched)
+		r.Span = state.Advance(len(matched))
 		return nil
 	}
-	return fmt.Errorf("expected string to match regex %q at %s", tag.Get("regex"), state.Location())
+	return state.Errorf("expected string to match regex %q", tag.Get("regex"))
 }
 
 type matching struct{}
@@ -88,7 +137,7 @@ type Open struct {
 }
 
 func (o Open) Annotate(m matching) error {
-	return fmt.Errorf("expected `)` to match `(` opened at %s", o.Literal.Location)
+	return fmt.Errorf("expected `)` to match `(` opened at %s", o.Literal.Span.Start)
 }
 
 type Close struct {
@@ -0,0 +1,77 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+)
+
+// LineComment is a comment running to the end of the line, introduced by the tag
+// comment:"<introducer>" (by default "//"), as in comment:"#" or comment:"--".
+// Contents holds the text after the introducer, without the line break.
+type LineComment struct {
+	Contents []byte
+	Span     Span
+}
+
+func (c *LineComment) ParseInto(state *State, tag reflect.StructTag) error {
+	introducer := tag.Get("comment")
+	if introducer == "" {
+		introducer = "//"
+	}
+	rest := state.Rest()
+	if !bytes.HasPrefix(rest, []byte(introducer)) {
+		return state.Errorf("Expected %q", introducer)
+	}
+	end := bytes.IndexAny(rest, "\r\n")
+	if end < 0 {
+		end = len(rest)
+	}
+	c.Contents = rest[len(introducer):end]
+	c.Span = state.Advance(end)
+	return nil
+}
+
+// BlockComment is a comment between the delimiters given by the tags open:"<open>" and
+// close:"<close>" (by default "/*" and "*/"). With nested:"true", comments nest, as in
+// OCaml and Haskell. Contents holds the text between the outermost delimiters.
+// An unterminated comment is a fatal error at the delimiter left open.
+type BlockComment struct {
+	Contents []byte
+	Span     Span
+}
+
+func (c *BlockComment) ParseInto(state *State, tag reflect.StructTag) error {
+	open, close := tag.Get("open"), tag.Get("close")
+	if open == "" && close == "" {
+		open, close = "/*", "*/"
+	}
+	if open == "" || close == "" {
+		panic(fmt.Sprintf("parse.BlockComment needs both 'open' and 'close' tags: %q", tag))
+	}
+	nested := tag.Get("nested") == "true"
+	rest := state.Rest()
+	if !bytes.HasPrefix(rest, []byte(open)) {
+		return state.Errorf("Expected %q", open)
+	}
+	// opened holds the offset of each delimiter still open.
+	opened := []int{0}
+	i := len(open)
+	for len(opened) > 0 {
+		switch {
+		case i >= len(rest):
+			state.PanicAt(state.Position+opened[len(opened)-1], "unterminated comment: expected %q to close %q", close, open)
+		case bytes.HasPrefix(rest[i:], []byte(close)):
+			opened = opened[:len(opened)-1]
+			i += len(close)
+		case nested && bytes.HasPrefix(rest[i:], []byte(open)):
+			opened = append(opened, i)
+			i += len(open)
+		default:
+			i++
+		}
+	}
+	c.Contents = rest[len(open) : i-len(close)]
+	c.Span = state.Advance(i)
+	return nil
+}
@@ -0,0 +1,68 @@
+package parse
+
+import "testing"
+
+type commentFile struct {
+	Items []commentItem
+}
+
+type commentItem struct {
+	Choice  `name:"item"`
+	Line    LineComment  `comment:"--"`
+	Hash    LineComment  `comment:"#"`
+	Block   BlockComment `open:"(*" close:"*)" nested:"true"`
+	C       BlockComment
+	Newline Literal `parse:"\n"`
+	Space   Literal `parse:" "`
+	Return  Literal `parse:"\r"`
+}
+
+func TestComments(t *testing.T) {
+	file := commentFile{}
+	source := "-- line\n# hash\r\n(* a (* nested *) b *) /* c (* */\n"
+	if err := Parse(source, &file); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got := []string{}
+	for _, item := range file.Items {
+		switch item.Choice.Index {
+		case 1:
+			got = append(got, string(item.Line.Contents))
+		case 2:
+			got = append(got, string(item.Hash.Contents))
+		case 3:
+			got = append(got, string(item.Block.Contents))
+		case 4:
+			got = append(got, string(item.C.Contents))
+		}
+	}
+	want := []string{" line", " hash", " a (* nested *) b ", " c (* "}
+	if len(got) != len(want) {
+		t.Fatalf("expected comments %q but got %q", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected comments %q but got %q", want, got)
+		}
+	}
+
+	err := Parse("(* a (* b *)\n", &commentFile{})
+	if err == nil || err.Error() != `unterminated comment: expected "*)" to close "(*" at 1:1` {
+		t.Errorf("expected an unterminated comment at its opening, got %v", err)
+	}
+	err = Parse("\n/* a\n", &commentFile{})
+	if err == nil || err.Error() != `unterminated comment: expected "*/" to close "/*" at 2:1` {
+		t.Errorf("expected an unterminated comment at its opening, got %v", err)
+	}
+}
+
+type commentLine struct {
+	Hash LineComment `comment:"#"`
+}
+
+func TestGenerateComments(t *testing.T) {
+	text, err := NewGenerator(1).Generate(&commentLine{})
+	if err != nil || text != "# comment" {
+		t.Errorf("expected only the comment to be generated, got %q (%v)", text, err)
+	}
+}
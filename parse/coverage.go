@@ -0,0 +1,232 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"reflect"
+	"text/tabwriter"
+)
+
+// FieldCoverage counts how a single field of a rule was exercised.
+// For alternations, Succeeded counts how often the field was the one chosen.
+// Present and Absent are only counted for optionals (non-nil or nil) and
+// repetitions (non-empty or empty).
+type FieldCoverage struct {
+	Field     string
+	Attempted int
+	Succeeded int
+	Present   int
+	Absent    int
+}
+
+// RuleCoverage collects the coverage of each field of a rule.
+type RuleCoverage struct {
+	Type   reflect.Type
+	Fields []*FieldCoverage
+}
+
+// Coverage records which parts of a grammar were exercised across any number of parses.
+// Pass it to Parse using WithCoverage.
+type Coverage struct {
+	Rules []*RuleCoverage
+	rules map[reflect.Type]*RuleCoverage
+}
+
+// NewCoverage creates an empty Coverage for the grammar of the type that target points to.
+// Every rule is listed in reports, including those never reached.
+func NewCoverage(target interface{}) *Coverage {
+	c := &Coverage{rules: map[reflect.Type]*RuleCoverage{}}
+	for _, node := range GraphOf(target).Nodes {
+		c.rule(node)
+	}
+	return c
+}
+
+// WithCoverage records the coverage of a parse into c.
+func WithCoverage(c *Coverage) Option {
+	return func(state *State) {
+		state.Coverage = c
+	}
+}
+
+func (c *Coverage) rule(into reflect.Type) *RuleCoverage {
+	if rule, ok := c.rules[into]; ok {
+		return rule
+	}
+	if c.rules == nil {
+		c.rules = map[reflect.Type]*RuleCoverage{}
+	}
+	rule := &RuleCoverage{Type: into}
+	for i := 0; i < into.NumField(); i++ {
+		rule.Fields = append(rule.Fields, &FieldCoverage{Field: into.Field(i).Name})
+	}
+	c.rules[into] = rule
+	c.Rules = append(c.Rules, rule)
+	return rule
+}
+
+// A coverageEvent is an attempt to parse a field of a rule, kept so that it can be counted
+// again when a memoized result containing it is reused.
+type coverageEvent struct {
+	into   reflect.Type
+	field  int
+	result interface{}
+	err    error
+}
+
+// recordCoverage counts an attempt to parse a field of a rule, if there is a Coverage.
+func (s *State) recordCoverage(into reflect.Type, field int, result interface{}, err error) {
+	if s.Coverage == nil {
+		return
+	}
+	s.Coverage.record(into, field, result, err)
+	s.coverageLog = append(s.coverageLog, coverageEvent{into, field, result, err})
+}
+
+// record counts an attempt to parse a field of a rule.
+func (c *Coverage) record(into reflect.Type, field int, result interface{}, err error) {
+	each := c.rule(into).Fields[field]
+	each.Attempted++
+	if err != nil {
+		return
+	}
+	each.Succeeded++
+	if !countsPresence(into.Field(field).Type) {
+		return
+	}
+	if value := reflect.ValueOf(result); value.IsNil() || value.Kind() == reflect.Slice && value.Len() == 0 {
+		each.Absent++
+	} else {
+		each.Present++
+	}
+}
+
+// countsPresence reports whether a field is an optional or a repetition.
+func countsPresence(into reflect.Type) bool {
+	return !isLeaf(into) && (into.Kind() == reflect.Ptr || into.Kind() == reflect.Slice)
+}
+
+// A CoverageGap describes a part of the grammar that was never exercised.
+type CoverageGap struct {
+	Type  reflect.Type
+	Field string
+	// Problem is one of "never chosen", "never present", "never absent", "never attempted".
+	Problem string
+}
+
+// Gaps lists the alternatives that were never chosen, and the optionals and
+// repetitions that were never present or never absent.
+func (c *Coverage) Gaps() []CoverageGap {
+	gaps := []CoverageGap{}
+	for _, rule := range c.Rules {
+		for i, field := range rule.Fields {
+			if problem := c.problem(rule.Type, i, field); problem != "" {
+				gaps = append(gaps, CoverageGap{rule.Type, field.Field, problem})
+			}
+		}
+	}
+	return gaps
+}
+
+func (c *Coverage) problem(into reflect.Type, i int, field *FieldCoverage) string {
+	if !isGrammarField(into, i) {
+		return ""
+	}
+	if isAlternation(into) {
+		if field.Succeeded == 0 {
+			return "never chosen"
+		}
+		return ""
+	}
+	if field.Attempted == 0 {
+		return "never attempted"
+	}
+	if countsPresence(into.Field(i).Type) {
+		if field.Present == 0 {
+			return "never present"
+		}
+		if field.Absent == 0 {
+			return "never absent"
+		}
+	}
+	return ""
+}
+
+// Text renders a plain-text report, with unexercised fields marked.
+func (c *Coverage) Text() string {
+	var out bytes.Buffer
+	writer := tabwriter.NewWriter(&out, 0, 4, 2, ' ', 0)
+	for _, rule := range c.Rules {
+		kind := "sequence"
+		if isAlternation(rule.Type) {
+			kind = "choice"
+		}
+		fmt.Fprintf(writer, "%s (%s)\n", rule.Type, kind)
+		for i, field := range rule.Fields {
+			if !isGrammarField(rule.Type, i) {
+				continue
+			}
+			fmt.Fprintf(writer, "\t%s\tattempted %d\tsucceeded %d\tpresent %d\tabsent %d", field.Field, field.Attempted, field.Succeeded, field.Present, field.Absent)
+			if problem := c.problem(rule.Type, i, field); problem != "" {
+				fmt.Fprintf(writer, "\t<- %s", problem)
+			}
+			fmt.Fprintf(writer, "\n")
+		}
+	}
+	writer.Flush()
+	return out.String()
+}
+
+var coverageTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Grammar coverage</title>
+<style>
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: left; }
+tr.gap { background: #fdd; }
+</style>
+</head>
+<body>
+{{range .}}<h2>{{.Name}} <small>({{.Kind}})</small></h2>
+<table>
+<tr><th>Field</th><th>Attempted</th><th>Succeeded</th><th>Present</th><th>Absent</th><th></th></tr>
+{{range .Fields}}<tr{{if .Problem}} class="gap"{{end}}><td>{{.Field}}</td><td>{{.Attempted}}</td><td>{{.Succeeded}}</td><td>{{.Present}}</td><td>{{.Absent}}</td><td>{{.Problem}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+// HTML renders the report as an HTML page, with unexercised fields highlighted.
+func (c *Coverage) HTML() string {
+	type row struct {
+		*FieldCoverage
+		Problem string
+	}
+	type section struct {
+		Name   string
+		Kind   string
+		Fields []row
+	}
+	sections := []section{}
+	for _, rule := range c.Rules {
+		each := section{Name: rule.Type.String(), Kind: "sequence"}
+		if isAlternation(rule.Type) {
+			each.Kind = "choice"
+		}
+		for i, field := range rule.Fields {
+			if !isGrammarField(rule.Type, i) {
+				continue
+			}
+			each.Fields = append(each.Fields, row{field, c.problem(rule.Type, i, field)})
+		}
+		sections = append(sections, each)
+	}
+	var out bytes.Buffer
+	if err := coverageTemplate.Execute(&out, sections); err != nil {
+		panic(err)
+	}
+	return out.String()
+}
@@ -0,0 +1,77 @@
+package parse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type coverageItem struct {
+	Choice `name:"item"`
+	A      Literal `parse:"a"`
+	B      Literal `parse:"b"`
+}
+
+type coverageList struct {
+	Items []coverageItem
+	End   *Literal `parse:";"`
+}
+
+func TestCoverage(t *testing.T) {
+	coverage := NewCoverage(new(coverageList))
+	for _, source := range []string{"aa;", "a;", ";"} {
+		var result coverageList
+		if err := Parse(source, &result, WithCoverage(coverage)); err != nil {
+			t.Fatalf("error ``%s'' unexpected", err)
+		}
+	}
+	gaps := coverage.Gaps()
+	if len(gaps) != 2 {
+		t.Fatalf("unexpected gaps %+v", gaps)
+	}
+	if gaps[0].Field != "End" || gaps[0].Problem != "never absent" {
+		t.Errorf("unexpected gap %+v", gaps[0])
+	}
+	if gaps[1].Field != "B" || gaps[1].Problem != "never chosen" {
+		t.Errorf("unexpected gap %+v", gaps[1])
+	}
+	if !strings.Contains(coverage.Text(), "<- never chosen") {
+		t.Errorf("expected gap to be marked in:\n%s", coverage.Text())
+	}
+	if !strings.Contains(coverage.HTML(), `<tr class="gap"><td>B</td>`) {
+		t.Errorf("expected gap to be highlighted in:\n%s", coverage.HTML())
+	}
+}
+
+type coverageStatement struct {
+	Choice `name:"statement"`
+	Call   coverageCall
+	Name   coverageName
+}
+
+type coverageCall struct {
+	Name coverageName
+	Open Literal `parse:"("`
+}
+
+type coverageName struct {
+	Choice `name:"name"`
+	Word   Regex `regex:"[a-z]+"`
+	Number Regex `regex:"[0-9]+"`
+}
+
+func TestCoverageMemoized(t *testing.T) {
+	// The name is parsed for the call, which fails, and is then reused from the memo for the
+	// second alternative. It's counted both times.
+	coverage := NewCoverage(new(coverageStatement))
+	if err := Parse("1", &coverageStatement{}, WithCoverage(coverage)); err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	number := coverage.rule(reflect.TypeOf(coverageName{})).Fields[2]
+	if number.Attempted != 2 || number.Succeeded != 2 {
+		t.Errorf("expected the number to be counted twice, but got %+v", number)
+	}
+	if name := coverage.rule(reflect.TypeOf(coverageStatement{})).Fields[2]; name.Succeeded != 1 {
+		t.Errorf("expected the name to be chosen once, but got %+v", name)
+	}
+}
@@ -27,22 +27,43 @@ var ParseIntoType = reflect.TypeOf((*ParseInto)(nil)).Elem()
 var ParseFailType = reflect.TypeOf((*ParseFail)(nil)).Elem()
 
 func parseIntoType(state *State, into reflect.Type, tag reflect.StructTag) (interface{}, error) {
-	input := Input{state.Position, into}
+	return parseIntoTypeMemo(state, into, tag, true)
+}
+
+// parseIntoTypeMemo parses into the type, reusing the result from an earlier attempt at the
+// same position. If fail is set, a type implementing ParseFail is told when it fails to parse.
+// Without fail, such a failure isn't remembered, so that the type is still told if it fails
+// again at the same position with fail set; a reused result never calls Failed.
+func parseIntoTypeMemo(state *State, into reflect.Type, tag reflect.StructTag, fail bool) (interface{}, error) {
+	input := Input{state.Position, into, tag, state.lexical}
 	if output, ok := state.Memory[input]; ok {
 		state.Position = output.Position
+		// The fields parsed the first time are counted again, as though parsed again.
+		for _, event := range output.coverage {
+			state.recordCoverage(event.into, event.field, event.result, event.err)
+		}
 		return output.Result, output.Error
 	}
-	if state.Learning[Input{state.Position, into}] {
+	if state.Learning[input] {
 		panic(fmt.Sprintf("An infinite loop has occurred- parsing %+v [%s] at %d", into, tag, state.Position))
 	}
-	state.Learning[Input{state.Position, into}] = true
+	state.Learning[input] = true
 	oldPosition := state.Position
+	mark := len(state.coverageLog)
 	value, err := parseIntoTypeCheck(state, into, tag)
+	if err != nil && fail {
+		callFailed(state, into)
+	}
 	if err != nil {
 		// Restore its position.
 		state.Position = oldPosition
 	}
-	state.Memory[input] = Output{value, err, state.Position}
+	if err != nil && !fail && into.Implements(ParseFailType) {
+		delete(state.Learning, input)
+		return value, err
+	}
+	end := len(state.coverageLog)
+	state.Memory[input] = Output{value, err, state.Position, state.coverageLog[mark:end:end], len(state.Memory)}
 	return value, err
 }
 
@@ -51,7 +72,7 @@ type fatalErrorNeedsLocation struct {
 }
 
 type fatalError struct {
-	Location string
+	Location Position
 	Message  interface{}
 }
 
@@ -65,27 +86,36 @@ func Panicf(format string, arguments ...interface{}) {
 	Panic(fmt.Sprintf(format, arguments...))
 }
 
-func parseIntoTypeCheck(state *State, into reflect.Type, tag reflect.StructTag) (interface{}, error) {
-	defer func() {
-		value := recover()
-		if value != nil {
-			if fatal, ok := value.(fatalErrorNeedsLocation); ok {
-				panic(fatalError{
-					Location: state.Location(),
-					Message:  fatal.Message,
-				})
-			}
-			// Lift the error if it's not the one we're looking for.
-			panic(value)
+// locateFatal gives the current location to a fatal error raised with Panic.
+func locateFatal(state *State) {
+	value := recover()
+	if value != nil {
+		if fatal, ok := value.(fatalErrorNeedsLocation); ok {
+			panic(fatalError{
+				Location: state.Location(),
+				Message:  fatal.Message,
+			})
 		}
-	}()
+		// Lift the error if it's not the one we're looking for.
+		panic(value)
+	}
+}
+
+// callFailed tells a type implementing ParseFail that it failed to parse.
+func callFailed(state *State, into reflect.Type) {
+	if !into.Implements(ParseFailType) {
+		return
+	}
+	defer locateFatal(state)
+	// This allows it to do whatever it needs to.
+	// TODO: does it need context somehow?
+	reflect.Zero(into).Interface().(ParseFail).Failed()
+}
+
+func parseIntoTypeCheck(state *State, into reflect.Type, tag reflect.StructTag) (interface{}, error) {
+	defer locateFatal(state)
 	value, err := parseIntoTypeRaw(state, into, tag)
 	if err != nil {
-		if into.Implements(ParseFailType) {
-			// This allows it to do whatever it needs to.
-			// TODO: does it need context somehow?
-			reflect.Zero(into).Interface().(ParseFail).Failed()
-		}
 		return nil, err
 	}
 	if checker, ok := value.(ParseCheck); ok {
@@ -101,6 +131,7 @@ func parseIntoTypeRaw(state *State, into reflect.Type, tag reflect.StructTag) (i
 		panic("Asked to parse into parse.Choice: probably a mistake.")
 	}
 	if pointerInto := reflect.PtrTo(into); pointerInto.Implements(ParseIntoType) {
+		skipTrivia(state)
 		value := reflect.New(pointerInto.Elem())
 		err := value.Interface().(ParseInto).ParseInto(state, tag)
 		if err != nil {
@@ -127,6 +158,10 @@ func parseIntoTypeRaw(state *State, into reflect.Type, tag reflect.StructTag) (i
 			panic("Cannot parse into both-way channel (maybe you meant a receive-only channel?)")
 		}
 	}
+	if isOneOfKind(into) && tag.Get("oneof") != "" {
+		skipTrivia(state)
+		return parseOneOf(state, into, tag)
+	}
 	if into.Kind() != reflect.Struct {
 		panic(fmt.Sprintf("into type %+v is not a pointer, slice, struct, or ParseInto.", into))
 	}
@@ -148,29 +183,39 @@ func parseIntoTypeCapture(state *State, into reflect.Type) (outValue interface{}
 		if !ok {
 			panic(recovered)
 		}
-		if asString, ok := fatal.Message.(string); ok {
-			outErr = fmt.Errorf("%s at %s", asString, fatal.Location)
-		}
-		if asError, ok := fatal.Message.(error); ok {
-			outErr = fmt.Errorf("%s at %s", asError.Error(), fatal.Location)
-		}
-		// TODO: stringer?
-		outErr = fmt.Errorf("%+v at %s", fatal.Message, fatal.Location)
+		// Strings, errors and Stringers all print as themselves.
+		outErr = &Error{Position: fatal.Location, Message: fmt.Sprintf("%+v", fatal.Message)}
 	}()
 	return parseIntoType(state, into, "")
 }
 
-func Parse(source string, target interface{}) error {
-	pointer := reflect.ValueOf(target)
-	if pointer.Kind() != reflect.Ptr {
-		panic("Parse given non-pointer.")
-	}
+// An Option configures a call to Parse.
+type Option func(*State)
+
+// newState prepares to parse source into the given type.
+func newState(source string, into reflect.Type, options []Option) *State {
 	state := &State{
 		Source:   []byte(source),
 		Position: 0,
 		Memory:   map[Input]Output{},
 		Learning: map[Input]bool{},
 	}
+	keywords := keywordsOf(into)
+	state.Reserved = keywords.exact
+	state.FoldedReserved = keywords.folded
+	for _, option := range options {
+		option(state)
+	}
+	state.prepare()
+	return state
+}
+
+func Parse(source string, target interface{}, options ...Option) error {
+	pointer := reflect.ValueOf(target)
+	if pointer.Kind() != reflect.Ptr {
+		panic("Parse given non-pointer.")
+	}
+	state := newState(source, pointer.Type().Elem(), options)
 	value, err := parseIntoTypeCapture(state, pointer.Type().Elem())
 	if err != nil {
 		return err
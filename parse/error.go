@@ -0,0 +1,35 @@
+package parse
+
+import "fmt"
+
+// An Error is a parse error at a position in the source.
+type Error struct {
+	Position Position
+	Message  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at %s", e.Message, e.Position)
+}
+
+// Errorf creates an Error at the current position.
+func (s *State) Errorf(format string, arguments ...interface{}) error {
+	return s.ErrorAt(s.Position, format, arguments...)
+}
+
+// ErrorAt creates an Error at the given byte offset into the source.
+func (s *State) ErrorAt(offset int, format string, arguments ...interface{}) error {
+	return &Error{
+		Position: s.PositionAt(offset),
+		Message:  fmt.Sprintf(format, arguments...),
+	}
+}
+
+// PanicAt stops parsing with a fatal error at the given byte offset into the source,
+// like Panicf does at the current position.
+func (s *State) PanicAt(offset int, format string, arguments ...interface{}) {
+	panic(fatalError{
+		Location: s.PositionAt(offset),
+		Message:  fmt.Sprintf(format, arguments...),
+	})
+}
@@ -0,0 +1,196 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+// A Pos is a compact position: the base of a file in a FileSet plus a byte offset into it.
+// Use FileSet.Position to turn it back into a file, line and column.
+type Pos int
+
+// NoPos is the zero Pos, which belongs to no file.
+const NoPos Pos = 0
+
+// A File is a source registered with a FileSet.
+// Positions in the file are computed using its Columns, TabWidth, LineEndings and SkipBOM.
+type File struct {
+	Name     string
+	Base     int
+	Source   []byte
+	Columns  ColumnUnit
+	TabWidth int
+	// LineEndings makes "\r\n" and a lone "\r" count as line breaks, along with "\n".
+	// Otherwise, "\r" only moves back to the first column.
+	LineEndings bool
+	// SkipBOM leaves a leading byte order mark out of the columns of the first line.
+	SkipBOM bool
+	// Token is the same source in a go/token FileSet, if one was given with WithTokenFile.
+	Token *token.File
+	// lines holds the offset at which each line starts.
+	lines []int
+	// decodedStarts and encodedStarts hold the offset at which each character starts in
+	// Source and in the original encoded source, if Source was decoded.
+	decodedStarts []int
+	encodedStarts []int
+	// scanned caches where the last column count stopped, so that positions further along
+	// the same line can carry on from it instead of from the start of the line.
+	scanned struct {
+		sync.Mutex
+		line, offset, column int
+		valid                bool
+	}
+}
+
+func newFile(name string, base int, source []byte) *File {
+	f := &File{Name: name, Base: base, Source: source}
+	f.computeLines()
+	return f
+}
+
+// computeLines fills in the line table, which depends on LineEndings.
+func (f *File) computeLines() {
+	f.scanned.valid = false
+	f.lines = []int{0}
+	for i, b := range f.Source {
+		if b == '\n' || f.LineEndings && b == '\r' && (i+1 == len(f.Source) || f.Source[i+1] != '\n') {
+			f.lines = append(f.lines, i+1)
+		}
+	}
+}
+
+// Pos converts a byte offset in the file into a compact Pos.
+func (f *File) Pos(offset int) Pos {
+	if offset < 0 || offset > len(f.Source) {
+		panic(fmt.Sprintf("offset %d out of range for file %q of size %d", offset, f.Name, len(f.Source)))
+	}
+	return Pos(f.Base + offset)
+}
+
+// Position finds the line and column of a byte offset into the file.
+func (f *File) Position(offset int) Position {
+	line := sort.Search(len(f.lines), func(i int) bool { return f.lines[i] > offset }) - 1
+	tabWidth := f.TabWidth
+	if tabWidth <= 0 {
+		tabWidth = 4
+	}
+	column := 0
+	start := f.lines[line]
+	if line == 0 && f.SkipBOM && bytes.HasPrefix(f.Source, byteOrderMark) && offset >= len(byteOrderMark) {
+		start = len(byteOrderMark)
+	}
+	f.scanned.Lock()
+	if f.scanned.valid && f.scanned.line == line && start <= f.scanned.offset && f.scanned.offset <= offset {
+		start, column = f.scanned.offset, f.scanned.column
+	}
+	i := start
+	for i < offset {
+		r, size := rune(f.Source[i]), 1
+		if f.Columns != ColumnBytes {
+			r, size = utf8.DecodeRune(f.Source[i:])
+		}
+		switch {
+		case r == '\r' && f.LineEndings:
+			// The line break is counted once the "\n" or the next line is reached.
+		case r == '\r':
+			column = 0
+		case r == '\t':
+			column /= tabWidth
+			column++
+			column *= tabWidth
+		case f.Columns == ColumnUTF16 && r >= 0x10000:
+			// Characters outside the basic multilingual plane take a surrogate pair.
+			column += 2
+		default:
+			column++
+		}
+		i += size
+	}
+	f.scanned.line, f.scanned.offset, f.scanned.column, f.scanned.valid = line, i, column, true
+	f.scanned.Unlock()
+	pos := NoPos
+	if f.Base > 0 {
+		pos = Pos(f.Base + offset)
+	}
+	tokenPos := token.NoPos
+	if f.Token != nil {
+		tokenPos = f.Token.Pos(offset)
+	}
+	encodedOffset := 0
+	if f.decodedStarts != nil {
+		encodedOffset = f.EncodedOffset(offset)
+	}
+	return Position{Filename: f.Name, Pos: pos, TokenPos: tokenPos, Offset: offset, EncodedOffset: encodedOffset, Line: line + 1, Column: column + 1, ByteColumn: offset - f.lines[line] + 1}
+}
+
+// EncodedOffset maps a byte offset into Source back to the original encoded source.
+// If the source was not decoded, the offsets are the same.
+func (f *File) EncodedOffset(offset int) int {
+	if f.decodedStarts == nil {
+		return offset
+	}
+	i := sort.Search(len(f.decodedStarts), func(i int) bool { return f.decodedStarts[i] > offset }) - 1
+	return f.encodedStarts[i] + offset - f.decodedStarts[i]
+}
+
+// A FileSet registers sources under their filenames, giving each a range of compact positions.
+// It's safe for concurrent use.
+type FileSet struct {
+	mutex sync.Mutex
+	files []*File
+	base  int
+}
+
+// NewFileSet creates an empty FileSet.
+func NewFileSet() *FileSet {
+	return &FileSet{base: 1}
+}
+
+// AddFile registers a source under a filename.
+// The file occupies the positions from its Base up to and including Base+len(source),
+// so that the end of the file has a position too.
+func (s *FileSet) AddFile(name string, source []byte) *File {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.base == 0 {
+		s.base = 1
+	}
+	f := newFile(name, s.base, source)
+	s.files = append(s.files, f)
+	s.base += len(source) + 1
+	return f
+}
+
+// File finds the file containing a compact position, or nil if there is none.
+func (s *FileSet) File(p Pos) *File {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	i := sort.Search(len(s.files), func(i int) bool { return s.files[i].Base > int(p) }) - 1
+	if i < 0 || int(p) > s.files[i].Base+len(s.files[i].Source) {
+		return nil
+	}
+	return s.files[i]
+}
+
+// Position converts a compact position into its file, line and column.
+// The zero Position is returned if p belongs to no file.
+func (s *FileSet) Position(p Pos) Position {
+	f := s.File(p)
+	if f == nil {
+		return Position{}
+	}
+	return f.Position(int(p) - f.Base)
+}
+
+// WithFile registers the source of a parse under the given filename in set, so that
+// positions and errors name the file.
+func WithFile(set *FileSet, name string) Option {
+	return func(state *State) {
+		state.FileSet = set
+		state.Filename = name
+	}
+}
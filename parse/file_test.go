@@ -0,0 +1,57 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+type fileExample struct {
+	Lines []fileLine
+}
+
+type fileLine struct {
+	Word    Regex   `regex:"[a-z]+"`
+	Newline Literal `parse:"\n"`
+}
+
+func TestFileSet(t *testing.T) {
+	set := NewFileSet()
+	var first, second fileExample
+	if err := Parse("ab\ncd\n", &first, WithFile(set, "first.dsl")); err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	if err := Parse("ef\ngh\n", &second, WithFile(set, "config/second.dsl")); err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	start := second.Lines[1].Word.Span.Start
+	if start.String() != "config/second.dsl:2:1" {
+		t.Errorf("unexpected position %s", start)
+	}
+	if found := set.Position(start.Pos); found != start {
+		t.Errorf("expected %+v but found %+v", start, found)
+	}
+	if found := set.Position(first.Lines[1].Word.Span.End.Pos); found.String() != "first.dsl:2:3" {
+		t.Errorf("unexpected position %s", found)
+	}
+	if set.File(NoPos) != nil {
+		t.Errorf("expected NoPos to belong to no file")
+	}
+	var line fileLine
+	err := Parse("ab", &line, WithFile(set, "third.dsl"))
+	if err == nil || !strings.Contains(err.Error(), "at third.dsl:1:3") {
+		t.Errorf("expected error to name the file: %s", err)
+	}
+}
+
+func TestFilePositionOrder(t *testing.T) {
+	// Columns are the same however the positions before them were asked for.
+	f := newFile("", 0, []byte("a\tbé\tc\nd\te"))
+	f.Columns = ColumnRunes
+	offsets := []int{0, 5, 2, 10, 6, 9, 3, 1, 7}
+	want := []string{"1:1", "1:7", "1:5", "2:5", "1:9", "2:2", "1:6", "1:2", "1:10"}
+	for i, offset := range offsets {
+		if got := f.Position(offset).String(); got != want[i] {
+			t.Errorf("offset %d: expected %s but got %s", offset, want[i], got)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package parse
+
+import "testing"
+
+type foldStatement struct {
+	Select Keyword `parse:"select" case:"fold"`
+	Space  Literal `parse:" "`
+	Column Identifier
+	Order  *foldOrder
+}
+
+type foldOrder struct {
+	Space     Literal `parse:" "`
+	Direction foldDirection
+}
+
+type foldDirection struct {
+	Choice     `name:"direction"`
+	Ascending  Literal `parse:"asc" case:"fold"`
+	Descending Literal `parse:"desc" case:"fold"`
+	Straße     Literal `parse:"straße" case:"fold"`
+}
+
+func TestFoldCase(t *testing.T) {
+	statement := foldStatement{}
+	if err := Parse("SeLeCt name DESC", &statement); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(statement.Select.Contents) != "SeLeCt" || string(statement.Order.Direction.Descending.Contents) != "DESC" {
+		t.Errorf("expected the original casing to be kept, got %+v", statement)
+	}
+	if statement.Order.Direction.Choice.Index != 2 {
+		t.Errorf("expected the descending alternative, got %+v", statement.Order.Direction.Choice)
+	}
+	if err := Parse("STRASSE", &foldDirection{}); err == nil {
+		t.Errorf("expected simple folding not to expand ß")
+	}
+	if err := Parse("select x STRAẞE", &statement); err != nil || statement.Order.Direction.Choice.Index != 3 {
+		t.Errorf("expected ẞ to fold to ß, got %+v (%v)", statement.Order, err)
+	}
+	if err := Parse("select SELECT", &statement); err == nil {
+		t.Errorf("expected a keyword in any case to be reserved")
+	}
+	// ſ (U+017F) folds to s, both when matching keywords and when reserving them.
+	if err := Parse("\u017Felect name", &statement); err != nil {
+		t.Errorf("expected \u017F to match s in a keyword, got %v", err)
+	}
+	if err := Parse("select \u017FELECT", &statement); err == nil {
+		t.Errorf("expected a keyword spelled with \u017F to be reserved")
+	}
+}
@@ -0,0 +1,508 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A Generator produces random strings accepted by a grammar.
+type Generator struct {
+	Rand *rand.Rand
+	// MaxDepth is the number of nested rules generated before the generator
+	// starts choosing the shortest way to finish.
+	MaxDepth int
+	// MaxRepeat bounds repetitions, both for slices and for regex repetition.
+	MaxRepeat int
+	// Attempts is the number of strings tried before Generate gives up.
+	// Since PEG choices are ordered and repetitions greedy, not every generated string
+	// parses as intended.
+	Attempts int
+}
+
+// NewGenerator creates a Generator with reasonable limits, seeded with the given value.
+func NewGenerator(seed int64) *Generator {
+	return &Generator{
+		Rand:      rand.New(rand.NewSource(seed)),
+		MaxDepth:  8,
+		MaxRepeat: 3,
+		Attempts:  100,
+	}
+}
+
+// Generate produces a random string for the type that target points to.
+// Every string is checked by parsing it into target with the given options; the first one
+// that parses is returned.
+func (g *Generator) Generate(target interface{}, options ...Option) (string, error) {
+	pointer := reflect.ValueOf(target)
+	if pointer.Kind() != reflect.Ptr {
+		panic("Generate given non-pointer.")
+	}
+	costs := ruleCosts(GraphOf(target))
+	into := pointer.Type().Elem()
+	if cost(into, costs) < 0 {
+		return "", fmt.Errorf("cannot generate %+v: its rules never terminate", into)
+	}
+	state := newState("", into, options)
+	if state.Trivia != nil {
+		for rule, each := range ruleCosts(GraphOf(reflect.New(state.Trivia).Interface())) {
+			costs[rule] = each
+		}
+	}
+	var lastErr error
+	for attempt := 0; attempt < g.Attempts; attempt++ {
+		var out bytes.Buffer
+		if err := g.generate(&out, into, "", g.MaxDepth, costs, state); err != nil {
+			return "", err
+		}
+		if err := Parse(out.String(), target, options...); err != nil {
+			lastErr = err
+			continue
+		}
+		return out.String(), nil
+	}
+	return "", fmt.Errorf("no generated string for %+v parsed after %d attempts: %s", into, g.Attempts, lastErr)
+}
+
+// ruleCosts computes for each rule the smallest depth of nesting needed to generate it.
+// Rules that can never finish are left out.
+func ruleCosts(graph *Graph) map[reflect.Type]int {
+	costs := map[reflect.Type]int{}
+	for changed := true; changed; {
+		changed = false
+		for _, node := range graph.Nodes {
+			best := -1
+			if !isAlternation(node) {
+				best = 0
+			}
+			for i := 0; i < node.NumField(); i++ {
+				if !isGrammarField(node, i) {
+					continue
+				}
+				each := cost(node.Field(i).Type, costs)
+				if isAlternation(node) {
+					if each >= 0 && (best < 0 || each < best) {
+						best = each
+					}
+				} else {
+					if each < 0 {
+						best = -1
+						break
+					}
+					if each > best {
+						best = each
+					}
+				}
+			}
+			if best < 0 {
+				continue
+			}
+			if old, ok := costs[node]; !ok || best+1 < old {
+				costs[node] = best + 1
+				changed = true
+			}
+		}
+	}
+	return costs
+}
+
+// cost is the smallest depth needed to generate a field of the given type, or -1 if unknown.
+func cost(into reflect.Type, costs map[reflect.Type]int) int {
+	if isLeaf(into) || isOneOfKind(into) {
+		return 0
+	}
+	switch into.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Chan:
+		return 0
+	case reflect.Struct:
+		if each, ok := costs[into]; ok {
+			return each
+		}
+	}
+	return -1
+}
+
+func (g *Generator) generate(out *bytes.Buffer, into reflect.Type, tag reflect.StructTag, depth int, costs map[reflect.Type]int, state *State) error {
+	if isLeaf(into) || isOneOfKind(into) && tag.Get("oneof") != "" || into.Kind() == reflect.Struct && isLexical(into) {
+		// Trivia is written where it would be skipped, before each token.
+		g.generateTrivia(out, costs, state)
+	}
+	if into.Kind() == reflect.Struct && isLexical(into) && !state.lexical {
+		state.lexical = true
+		defer func() {
+			state.lexical = false
+		}()
+	}
+	if isLeaf(into) {
+		return g.generateLeaf(out, into, tag, state)
+	}
+	switch into.Kind() {
+	case reflect.Ptr:
+		if depth > 0 && g.Rand.Intn(2) == 0 {
+			return g.generate(out, into.Elem(), tag, depth, costs, state)
+		}
+		return nil
+	case reflect.Slice:
+		count := 0
+		if depth > 0 {
+			count = g.Rand.Intn(g.MaxRepeat + 1)
+		}
+		for i := 0; i < count; i++ {
+			if err := g.generate(out, into.Elem(), tag, depth, costs, state); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Chan:
+		// Lookaheads consume nothing; whether they hold is checked when the string is parsed.
+		return nil
+	case reflect.Struct:
+	default:
+		if isOneOfKind(into) && tag.Get("oneof") != "" {
+			spellings := oneOfSpellings(into, tag)
+			out.Write(spellings[g.Rand.Intn(len(spellings))].spelling)
+			return nil
+		}
+		panic(fmt.Sprintf("into type %+v is not a pointer, slice, struct, or ParseInto.", into))
+	}
+	if isAlternation(into) {
+		options := []int{}
+		for i := 1; i < into.NumField(); i++ {
+			if !isGrammarField(into, i) {
+				continue
+			}
+			each := cost(into.Field(i).Type, costs)
+			if each < 0 {
+				continue
+			}
+			if depth <= 0 && each+1 != costs[into] {
+				continue
+			}
+			options = append(options, i)
+		}
+		if len(options) == 0 {
+			return fmt.Errorf("cannot generate %+v: no alternative terminates", into)
+		}
+		field := into.Field(options[g.Rand.Intn(len(options))])
+		return g.generate(out, field.Type, field.Tag, depth-1, costs, state)
+	}
+	for i := 0; i < into.NumField(); i++ {
+		// An included field's contents come from another file, named by an earlier field.
+		if !isGrammarField(into, i) || into.Field(i).Tag.Get("include") != "" {
+			continue
+		}
+		var err error
+		withoutTrivia(state, into.Field(i).Tag.Get("trivia") == "-", func() {
+			err = g.generate(out, into.Field(i).Type, into.Field(i).Tag, depth-1, costs, state)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generateTrivia sometimes writes trivia before a token, when the grammar has trivia and
+// it isn't turned off. Trivia that would run on into the token, as a line comment does,
+// is ended with a line break, or left out if that doesn't end it.
+func (g *Generator) generateTrivia(out *bytes.Buffer, costs map[reflect.Type]int, state *State) {
+	if state.Trivia == nil || state.lexical || g.Rand.Intn(2) == 0 {
+		return
+	}
+	var trivia bytes.Buffer
+	state.lexical = true
+	err := g.generate(&trivia, state.Trivia, "", g.MaxDepth, costs, state)
+	state.lexical = false
+	if err != nil {
+		return
+	}
+	for _, end := range []string{"", "\n"} {
+		text := trivia.String() + end
+		if triviaLength(state.Trivia, text+"\x00") == len(text) {
+			out.WriteString(text)
+			return
+		}
+	}
+}
+
+// triviaLength is the number of bytes at the start of source that are skipped as trivia.
+func triviaLength(trivia reflect.Type, source string) int {
+	state := newState(source, trivia, nil)
+	state.lexical = true
+	if _, err := parseIntoTypeCapture(state, trivia); err != nil {
+		return 0
+	}
+	return state.Position
+}
+
+// generateLeaf writes a random token for a leaf. The state holds the options that the
+// token is parsed with, such as the reserved words.
+func (g *Generator) generateLeaf(out *bytes.Buffer, into reflect.Type, tag reflect.StructTag, state *State) error {
+	switch into {
+	case reflect.TypeOf(Literal{}), reflect.TypeOf(Keyword{}):
+		for _, r := range tag.Get("parse") {
+			if tag.Get("case") == "fold" && g.Rand.Intn(2) == 0 {
+				r = unicode.SimpleFold(r)
+			}
+			out.WriteRune(r)
+		}
+	case reflect.TypeOf(Location{}):
+	case reflect.TypeOf(Number{}):
+		fmt.Fprintf(out, "%d", g.Rand.Intn(2000)-1000)
+		if g.Rand.Intn(2) == 0 {
+			fmt.Fprintf(out, ".%d", g.Rand.Intn(100))
+		}
+	case reflect.TypeOf(Int{}), reflect.TypeOf(Uint{}):
+		signed := into == reflect.TypeOf(Int{})
+		signs, ok := tag.Lookup("sign")
+		if !ok && signed {
+			signs = "-"
+		}
+		// limit is the largest magnitude that fits in the field's bits.
+		bits := integerBits(tag)
+		limit := ^uint64(0) >> uint(64-bits)
+		negative := false
+		if signed {
+			negative = strings.Contains(signs, "-") && g.Rand.Intn(2) == 0
+			limit >>= 1
+			if negative {
+				limit++
+			}
+		}
+		// Small values are usual, but the limit itself is sometimes picked.
+		magnitude := uint64(g.Rand.Intn(100))
+		if magnitude > limit {
+			magnitude = uint64(g.Rand.Int63n(int64(limit) + 1))
+		}
+		if g.Rand.Intn(8) == 0 {
+			magnitude = limit
+		}
+		switch {
+		case negative:
+			out.WriteByte('-')
+		case strings.Contains(signs, "+") && g.Rand.Intn(4) == 0:
+			out.WriteByte('+')
+		}
+		base := 10
+		if bases := tag.Get("bases"); bases != "" && !strings.Contains(","+bases+",", ",10,") {
+			fmt.Sscan(strings.Split(bases, ",")[0], &base)
+			out.WriteString(integerPrefixes[base])
+		}
+		out.WriteString(strconv.FormatUint(magnitude, base))
+	case reflect.TypeOf(String{}):
+		quote := '"'
+		if quotes := []rune(tag.Get("quotes")); len(quotes) > 0 {
+			quote = quotes[g.Rand.Intn(len(quotes))]
+		}
+		out.WriteRune(quote)
+		for i := g.Rand.Intn(g.MaxRepeat + 1); i > 0; i-- {
+			out.WriteRune(rune('a' + g.Rand.Intn(26)))
+		}
+		out.WriteRune(quote)
+	case reflect.TypeOf(LineComment{}):
+		fmt.Fprintf(out, "%s comment", commentDefault(tag.Get("comment"), "//"))
+	case reflect.TypeOf(BlockComment{}):
+		fmt.Fprintf(out, "%s comment %s", commentDefault(tag.Get("open"), "/*"), commentDefault(tag.Get("close"), "*/"))
+	case reflect.TypeOf(Duration{}):
+		out.WriteString(time.Duration(g.Rand.Int63n(int64(48 * time.Hour))).Round(time.Second).String())
+	case reflect.TypeOf(Time{}):
+		out.WriteString(time.Unix(g.Rand.Int63n(2e9), 0).UTC().Format(timeLayout(tag)))
+	case reflect.TypeOf(Addr{}):
+		fmt.Fprintf(out, "10.%d.%d.%d", g.Rand.Intn(256), g.Rand.Intn(256), g.Rand.Intn(256))
+	case reflect.TypeOf(Prefix{}):
+		fmt.Fprintf(out, "10.%d.0.0/16", g.Rand.Intn(256))
+	case reflect.TypeOf(AddrPort{}):
+		fmt.Fprintf(out, "10.0.0.%d:%d", g.Rand.Intn(256), g.Rand.Intn(65536))
+	case reflect.TypeOf(UnicodeIdentifier{}):
+		extraStart := identifierClass(tag, "extraStart", "")
+		extraContinue := identifierClass(tag, "extraContinue", "")
+		name, ok := g.generateIdentifier(state, func() (rune, bool) {
+			return g.generateRune(func(r rune) bool { return isXIDStart(r) || extraStart.matches(r) })
+		}, func() (rune, bool) {
+			return g.generateRune(func(r rune) bool { return isXIDContinue(r) || extraStart.matches(r) || extraContinue.matches(r) })
+		})
+		if !ok {
+			return fmt.Errorf("cannot generate an identifier that isn't reserved")
+		}
+		out.WriteString(name)
+	case reflect.TypeOf(Identifier{}):
+		starts := identifierClass(tag, "start", identifierStart)
+		continues := identifierClass(tag, "continue", identifierContinue)
+		name, ok := g.generateIdentifier(state, func() (rune, bool) {
+			return g.generateCharacter(starts)
+		}, func() (rune, bool) {
+			return g.generateCharacter(continues)
+		})
+		if !ok {
+			return fmt.Errorf("cannot generate an identifier that isn't reserved")
+		}
+		out.WriteString(name)
+	case reflect.TypeOf(Class{}):
+		class := classOf(tag)
+		count := class.min + g.Rand.Intn(g.MaxRepeat+1)
+		if class.max >= 0 && count > class.max {
+			count = class.max
+		}
+		for i := 0; i < count; i++ {
+			r, ok := g.generateCharacter(class)
+			if !ok && i >= class.min {
+				// The class may hold no character at all; it's still valid when optional.
+				break
+			}
+			if !ok {
+				return fmt.Errorf("cannot generate a character in class %q", class.source)
+			}
+			out.WriteRune(r)
+		}
+	case reflect.TypeOf(Regex{}):
+		regex, err := syntax.Parse(tag.Get("regex"), syntax.Perl)
+		if err != nil {
+			return err
+		}
+		return g.generateRegex(out, regex.Simplify())
+	default:
+		return fmt.Errorf("cannot generate leaf %+v", into)
+	}
+	return nil
+}
+
+// generateRegex writes a random string matching the regular expression.
+func (g *Generator) generateRegex(out *bytes.Buffer, regex *syntax.Regexp) error {
+	switch regex.Op {
+	case syntax.OpLiteral:
+		for _, r := range regex.Rune {
+			if regex.Flags&syntax.FoldCase != 0 && g.Rand.Intn(2) == 0 {
+				r = unicode.SimpleFold(r)
+			}
+			out.WriteRune(r)
+		}
+	case syntax.OpCharClass:
+		r, ok := g.generateClass(regex.Rune)
+		if !ok {
+			return fmt.Errorf("cannot generate a character in regex class %s", regex)
+		}
+		out.WriteRune(r)
+	case syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		out.WriteRune(rune(' ' + g.Rand.Intn('~'-' '+1)))
+	case syntax.OpCapture:
+		return g.generateRegex(out, regex.Sub[0])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := regex.Min, regex.Max
+		switch regex.Op {
+		case syntax.OpStar:
+			min, max = 0, -1
+		case syntax.OpPlus:
+			min, max = 1, -1
+		case syntax.OpQuest:
+			min, max = 0, 1
+		}
+		if max < 0 || max > min+g.MaxRepeat {
+			max = min + g.MaxRepeat
+		}
+		count := min + g.Rand.Intn(max-min+1)
+		for i := 0; i < count; i++ {
+			if err := g.generateRegex(out, regex.Sub[0]); err != nil {
+				return err
+			}
+		}
+	case syntax.OpConcat:
+		for _, sub := range regex.Sub {
+			if err := g.generateRegex(out, sub); err != nil {
+				return err
+			}
+		}
+	case syntax.OpAlternate:
+		return g.generateRegex(out, regex.Sub[g.Rand.Intn(len(regex.Sub))])
+	}
+	// Empty matches and assertions produce nothing.
+	return nil
+}
+
+// generateClass picks a rune from a class given as pairs of inclusive ranges.
+// Printable ASCII is preferred so that generated strings stay readable. It fails if the
+// class is empty, as a negated class that excludes every character is.
+func (g *Generator) generateClass(ranges []rune) (rune, bool) {
+	if len(ranges) < 2 {
+		return 0, false
+	}
+	for attempt := 0; attempt < 16; attempt++ {
+		r := rune(' ' + g.Rand.Intn('~'-' '+1))
+		for i := 0; i+1 < len(ranges); i += 2 {
+			if ranges[i] <= r && r <= ranges[i+1] {
+				return r, true
+			}
+		}
+	}
+	i := 2 * g.Rand.Intn(len(ranges)/2)
+	return ranges[i] + rune(g.Rand.Int63n(int64(ranges[i+1]-ranges[i])+1)), true
+}
+
+// generateIdentifier picks an identifier that isn't reserved, with characters picked by
+// start and next.
+func (g *Generator) generateIdentifier(state *State, start, next func() (rune, bool)) (string, bool) {
+	for attempt := 0; attempt < 16; attempt++ {
+		var name strings.Builder
+		first, ok := start()
+		if !ok {
+			return "", false
+		}
+		name.WriteRune(first)
+		for i := g.Rand.Intn(g.MaxRepeat + 1); i > 0; i-- {
+			if r, ok := next(); ok {
+				name.WriteRune(r)
+			}
+		}
+		if !state.isReserved(name.String()) && (state.IsNFC == nil || state.IsNFC(name.String())) {
+			return name.String(), true
+		}
+	}
+	return "", false
+}
+
+// generateRune picks a character that allowed accepts. Printable ASCII is preferred, but
+// characters from the rest of the basic multilingual plane are sometimes tried.
+func (g *Generator) generateRune(allowed func(rune) bool) (rune, bool) {
+	for attempt := 0; attempt < 256; attempt++ {
+		r := rune(' ' + g.Rand.Intn('~'-' '+1))
+		if attempt%4 == 3 {
+			r = rune(0xA0 + g.Rand.Intn(0xD800-0xA0))
+		}
+		if allowed(r) {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+// generateCharacter picks a character in a class, preferring printable ASCII.
+func (g *Generator) generateCharacter(class *characterClass) (rune, bool) {
+	if r, ok := g.generateRune(class.matches); ok {
+		return r, true
+	}
+	candidates := []rune{}
+	for _, each := range class.ranges {
+		// The characters just outside a range are tried too, for negated classes.
+		candidates = append(candidates, each[0], each[0]-1, each[1]+1)
+	}
+	for _, table := range class.tables {
+		for _, each := range table.R16 {
+			candidates = append(candidates, rune(each.Lo))
+		}
+		for _, each := range table.R32 {
+			candidates = append(candidates, rune(each.Lo))
+		}
+	}
+	for _, r := range candidates {
+		if utf8.ValidRune(r) && class.matches(r) {
+			return r, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,151 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type generateName struct {
+	Space Regex `regex:"\\s*"`
+	Name  Regex `regex:"[a-z]+"`
+}
+
+type generateCall struct {
+	Open      Open
+	Function  generateName
+	Arguments []generateExpression
+	Close     Close
+}
+
+type generateExpression struct {
+	Choice   `name:"expression"`
+	Call     generateCall
+	Variable generateName
+	Number   Number
+}
+
+func TestGenerate(t *testing.T) {
+	generator := NewGenerator(1)
+	for i := 0; i < 20; i++ {
+		var result generateCall
+		text, err := generator.Generate(&result)
+		if err != nil {
+			t.Fatalf("error ``%s'' unexpected", err)
+		}
+		if text == "" || text[0] != '(' {
+			t.Errorf("unexpected generated text %q", text)
+		}
+	}
+}
+
+func TestGenerateDeterministic(t *testing.T) {
+	var result generateExpression
+	first, err := NewGenerator(7).Generate(&result)
+	if err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	second, err := NewGenerator(7).Generate(&result)
+	if err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	if first == "" {
+		t.Fatalf("expected generated text")
+	}
+	if first != second {
+		t.Errorf("expected the same seed to generate the same text, but got %q and %q", first, second)
+	}
+	if err := Parse(first, &result); err != nil {
+		t.Errorf("error ``%s'' unexpected parsing generated text %q", err, first)
+	}
+}
+
+type generateNothing struct {
+	Never Regex `regex:"[^\\x00-\\x{10FFFF}]"`
+}
+
+type generateOptionalNothing struct {
+	Open    Literal `parse:"("`
+	Nothing Class   "class:\"^\x00-\U0010FFFF\" min:\"0\""
+	Close   Literal `parse:")"`
+}
+
+type generateInclude struct {
+	Path     Literal          `parse:"\"part.txt\""`
+	Included generateIncluded `include:"Path"`
+}
+
+type generateIncluded struct {
+	Word Literal `parse:"included"`
+}
+
+func TestGenerateEmptyClass(t *testing.T) {
+	if _, err := NewGenerator(1).Generate(&generateNothing{}); err == nil {
+		t.Errorf("expected an error for a class with no characters")
+	}
+	text, err := NewGenerator(1).Generate(&generateOptionalNothing{})
+	if err != nil || text != "()" {
+		t.Errorf("expected %q but got %q (%v)", "()", text, err)
+	}
+}
+
+func TestGenerateInclude(t *testing.T) {
+	// The included file's contents aren't generated, since they're read from the file.
+	fsys := fstest.MapFS{"part.txt": {Data: []byte("included")}}
+	text, err := NewGenerator(1).Generate(&generateInclude{}, WithFS(fsys))
+	if err != nil || text != `"part.txt"` {
+		t.Errorf("expected only the path but got %q (%v)", text, err)
+	}
+}
+
+type generateSmall struct {
+	Signed   Int     `bits:"4"`
+	Space    Literal `parse:" "`
+	Unsigned Uint    `bits:"4" bases:"16" sign:"+"`
+}
+
+func TestGenerateIntegerBits(t *testing.T) {
+	generator := NewGenerator(1)
+	seen := map[int64]bool{}
+	for i := 0; i < 200; i++ {
+		var result generateSmall
+		text, err := generator.Generate(&result)
+		if err != nil {
+			t.Fatalf("error ``%s'' unexpected", err)
+		}
+		if result.Signed.Int < -8 || result.Signed.Int > 7 || result.Unsigned.Uint > 15 {
+			t.Fatalf("generated %q out of range: %+v", text, result)
+		}
+		seen[result.Signed.Int] = true
+	}
+	for _, want := range []int64{-8, -1, 0, 7} {
+		if !seen[want] {
+			t.Errorf("expected %d to be generated, got %v", want, seen)
+		}
+	}
+}
+
+func TestGenerateTrivia(t *testing.T) {
+	generator := NewGenerator(1)
+	spaced, commented := false, false
+	for i := 0; i < 50; i++ {
+		var list triviaList
+		text, err := generator.Generate(&list, WithTrivia(&triviaSpace{}))
+		if err != nil {
+			t.Fatalf("error ``%s'' unexpected", err)
+		}
+		spaced = spaced || strings.ContainsAny(text, " \t\n")
+		commented = commented || strings.Contains(text, ";") || strings.Contains(text, "#|")
+		var pair triviaPair
+		text, err = generator.Generate(&pair, WithTrivia(&triviaSpace{}))
+		if err != nil {
+			t.Fatalf("error ``%s'' unexpected", err)
+		}
+		if strings.Contains(text, ": ") || strings.Contains(text, ":;") || strings.Contains(text, ":#") {
+			t.Errorf("expected no trivia before a trivia:\"-\" field, got %q", text)
+		}
+	}
+	if !spaced || !commented {
+		t.Errorf("expected spaces and comments to be generated between tokens")
+	}
+}
@@ -0,0 +1,46 @@
+package parse
+
+import (
+	"go/scanner"
+	"go/token"
+)
+
+// WithTokenFile adds the source of a parse to a go/token FileSet as a *token.File with
+// its line table populated, so that positions also carry a token.Pos. If no filename
+// was given with WithFile, name is used.
+func WithTokenFile(set *token.FileSet, name string) Option {
+	return func(state *State) {
+		state.TokenFileSet = set
+		if state.Filename == "" {
+			state.Filename = name
+		}
+	}
+}
+
+// TokenPosition converts the position into a go/token Position, whose column is the
+// ByteColumn rather than the Column counted by the parse.
+func (p Position) TokenPosition() token.Position {
+	return token.Position{
+		Filename: p.Filename,
+		Offset:   p.Offset,
+		Line:     p.Line,
+		Column:   p.ByteColumn,
+	}
+}
+
+// ScannerError converts the error into a go/scanner Error, for use in a scanner.ErrorList.
+func (e *Error) ScannerError() *scanner.Error {
+	return &scanner.Error{Pos: e.Position.TokenPosition(), Msg: e.Message}
+}
+
+// tokenFile adds a file to a go/token FileSet, with the same lines.
+func tokenFile(set *token.FileSet, f *File) *token.File {
+	file := set.AddFile(f.Name, -1, len(f.Source))
+	lines := f.lines
+	if len(lines) > 1 && lines[len(lines)-1] == len(f.Source) {
+		// go/token has no line starting at the very end of the file.
+		lines = lines[:len(lines)-1]
+	}
+	file.SetLines(lines)
+	return file
+}
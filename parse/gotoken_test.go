@@ -0,0 +1,44 @@
+package parse
+
+import (
+	"errors"
+	"go/scanner"
+	"go/token"
+	"testing"
+)
+
+func TestTokenFile(t *testing.T) {
+	set := token.NewFileSet()
+	var result fileExample
+	if err := Parse("ab\ncd\n", &result, WithTokenFile(set, "example.dsl")); err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	start := result.Lines[1].Word.Span.Start
+	if found := set.Position(start.TokenPos); found != start.TokenPosition() {
+		t.Errorf("expected %+v but found %+v", start.TokenPosition(), found)
+	}
+
+	var words encodingExample
+	if err := Parse("é\tab", &words, WithTokenFile(set, "columns.dsl"), WithColumns(ColumnRunes)); err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	start = words.Words[1].Word.Span.Start
+	if start.Column != 5 {
+		t.Errorf("expected column 5 but got %d", start.Column)
+	}
+	if found := set.Position(start.TokenPos); found != start.TokenPosition() || found.Column != 4 {
+		t.Errorf("expected %+v with byte column 4 but found %+v", found, start.TokenPosition())
+	}
+
+	var line fileLine
+	err := Parse("ab", &line, WithTokenFile(set, "broken.dsl"))
+	var parseError *Error
+	if !errors.As(err, &parseError) {
+		t.Fatalf("expected a parse.Error but got %v", err)
+	}
+	var list scanner.ErrorList
+	list = append(list, parseError.ScannerError())
+	if list.Error() != `broken.dsl:1:3: Expected "\n"` {
+		t.Errorf("unexpected error list %q", list.Error())
+	}
+}
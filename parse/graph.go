@@ -0,0 +1,344 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// An EdgeKind describes how a rule refers to another rule.
+type EdgeKind string
+
+const (
+	EdgeSequence  EdgeKind = "sequence"
+	EdgeChoice    EdgeKind = "choice"
+	EdgeOptional  EdgeKind = "optional"
+	EdgeMany      EdgeKind = "many"
+	EdgeLookahead EdgeKind = "lookahead"
+	EdgeNegative  EdgeKind = "negative"
+)
+
+// An Edge is a reference from one rule to another through a field.
+// Kinds lists the wrappers from outermost to innermost, so that a field of type
+// []*T in a sequence has the kinds sequence, many, optional.
+type Edge struct {
+	From  reflect.Type
+	To    reflect.Type
+	Field string
+	Kinds []EdgeKind
+	// Left is set when the field can be reached without consuming any input.
+	Left bool
+	// Recursive is set when the edge lies on a cycle.
+	Recursive bool
+	// LeftRecursive is set when the edge lies on a cycle of Left edges.
+	LeftRecursive bool
+}
+
+// A Graph is the type-reference graph of a grammar.
+// Nodes are the struct types used as rules, in the order they were discovered from Root.
+type Graph struct {
+	Root  reflect.Type
+	Nodes []reflect.Type
+	Edges []Edge
+}
+
+// GraphOf builds the graph of the grammar rooted at the type pointed to by target.
+func GraphOf(target interface{}) *Graph {
+	pointer := reflect.TypeOf(target)
+	if pointer == nil || pointer.Kind() != reflect.Ptr {
+		panic("GraphOf given non-pointer.")
+	}
+	g := &Graph{Root: ruleOf(pointer.Elem())}
+	seen := map[reflect.Type]bool{}
+	queue := []reflect.Type{g.Root}
+	if g.Root == nil {
+		return g
+	}
+	seen[g.Root] = true
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		g.Nodes = append(g.Nodes, node)
+		first := 0
+		kind := EdgeSequence
+		if isAlternation(node) {
+			first = 1
+			kind = EdgeChoice
+		}
+		for i := first; i < node.NumField(); i++ {
+			if !isGrammarField(node, i) {
+				continue
+			}
+			field := node.Field(i)
+			kinds := []EdgeKind{kind}
+			to := field.Type
+			for to != nil && !isLeaf(to) && to.Kind() != reflect.Struct {
+				k, ok := wrapperKind(to)
+				if !ok {
+					to = nil
+					break
+				}
+				kinds = append(kinds, k)
+				to = to.Elem()
+			}
+			if to == nil || isLeaf(to) {
+				continue
+			}
+			g.Edges = append(g.Edges, Edge{From: node, To: to, Field: field.Name, Kinds: kinds})
+			if !seen[to] {
+				seen[to] = true
+				queue = append(queue, to)
+			}
+		}
+	}
+	g.markLeft()
+	g.MarkCycles()
+	return g
+}
+
+// ruleOf strips wrappers from a type until it reaches a struct rule, or returns nil for leaves.
+func ruleOf(into reflect.Type) reflect.Type {
+	for !isLeaf(into) && into.Kind() != reflect.Struct {
+		if _, ok := wrapperKind(into); !ok {
+			return nil
+		}
+		into = into.Elem()
+	}
+	if isLeaf(into) {
+		return nil
+	}
+	return into
+}
+
+func isLeaf(into reflect.Type) bool {
+	return reflect.PtrTo(into).Implements(ParseIntoType)
+}
+
+func isAlternation(into reflect.Type) bool {
+	return into.Kind() == reflect.Struct && into.NumField() > 0 && into.Field(0).Type == reflect.TypeOf(Choice{})
+}
+
+// isGrammarField reports whether a field of a rule is parsed, rather than being its
+// Choice, a span, or a Lexical marker.
+func isGrammarField(into reflect.Type, i int) bool {
+	if isAlternation(into) && i == 0 {
+		return false
+	}
+	if _, ok := spannedField(into.Field(i)); ok {
+		return false
+	}
+	return !isSpanField(into.Field(i)) && into.Field(i).Type != lexicalType
+}
+
+func wrapperKind(into reflect.Type) (EdgeKind, bool) {
+	switch into.Kind() {
+	case reflect.Ptr:
+		return EdgeOptional, true
+	case reflect.Slice:
+		return EdgeMany, true
+	case reflect.Chan:
+		if into.ChanDir() == reflect.SendDir {
+			return EdgeNegative, true
+		}
+		return EdgeLookahead, true
+	}
+	return "", false
+}
+
+// nullable reports whether a field of the given type and tag can succeed without consuming input.
+// The assumed map holds the current guess for each struct rule.
+func nullable(into reflect.Type, tag reflect.StructTag, assumed map[reflect.Type]bool) bool {
+	switch into {
+	case reflect.TypeOf(Literal{}), reflect.TypeOf(Number{}):
+		return false
+	case reflect.TypeOf(Location{}):
+		return true
+	case reflect.TypeOf(Regex{}):
+		regex, err := regexp.Compile(tag.Get("regex"))
+		return err == nil && regex.MatchString("")
+	case reflect.TypeOf(Class{}):
+		return classOf(tag).min == 0
+	}
+	if isLeaf(into) {
+		return false
+	}
+	if _, ok := wrapperKind(into); ok {
+		return true
+	}
+	if into.Kind() != reflect.Struct {
+		return false
+	}
+	return assumed[into]
+}
+
+// markLeft computes which rules are nullable, and from that which edges are in a leftmost position.
+func (g *Graph) markLeft() {
+	assumed := map[reflect.Type]bool{}
+	for changed := true; changed; {
+		changed = false
+		for _, node := range g.Nodes {
+			if assumed[node] {
+				continue
+			}
+			result := !isAlternation(node)
+			for i := 0; i < node.NumField(); i++ {
+				if !isGrammarField(node, i) {
+					continue
+				}
+				each := nullable(node.Field(i).Type, node.Field(i).Tag, assumed)
+				if isAlternation(node) {
+					result = result || each
+				} else {
+					result = result && each
+				}
+			}
+			if result {
+				assumed[node] = true
+				changed = true
+			}
+		}
+	}
+	for i := range g.Edges {
+		edge := &g.Edges[i]
+		if isAlternation(edge.From) {
+			edge.Left = true
+			continue
+		}
+		edge.Left = true
+		for f := 0; f < edge.From.NumField(); f++ {
+			field := edge.From.Field(f)
+			if field.Name == edge.Field {
+				break
+			}
+			if isGrammarField(edge.From, f) && !nullable(field.Type, field.Tag, assumed) {
+				edge.Left = false
+				break
+			}
+		}
+	}
+}
+
+// MarkCycles sets Recursive and LeftRecursive on each edge, by finding the strongly
+// connected components of the graph and of its Left edges. GraphOf calls it; other
+// builders of graphs, such as package peg, call it once their edges are complete.
+func (g *Graph) MarkCycles() {
+	all := components(g.Nodes, g.Edges, func(Edge) bool { return true })
+	left := components(g.Nodes, g.Edges, func(e Edge) bool { return e.Left })
+	for i := range g.Edges {
+		edge := &g.Edges[i]
+		edge.Recursive = all[edge.From] == all[edge.To]
+		edge.LeftRecursive = edge.Left && left[edge.From] == left[edge.To]
+	}
+}
+
+// components labels each node with its strongly connected component, using Tarjan's algorithm.
+func components(nodes []reflect.Type, edges []Edge, use func(Edge) bool) map[reflect.Type]int {
+	successors := map[reflect.Type][]reflect.Type{}
+	for _, edge := range edges {
+		if use(edge) {
+			successors[edge.From] = append(successors[edge.From], edge.To)
+		}
+	}
+	index := map[reflect.Type]int{}
+	low := map[reflect.Type]int{}
+	onStack := map[reflect.Type]bool{}
+	stack := []reflect.Type{}
+	component := map[reflect.Type]int{}
+	count := 0
+	var visit func(node reflect.Type)
+	visit = func(node reflect.Type) {
+		index[node] = len(index)
+		low[node] = index[node]
+		stack = append(stack, node)
+		onStack[node] = true
+		for _, next := range successors[node] {
+			if _, ok := index[next]; !ok {
+				visit(next)
+				if low[next] < low[node] {
+					low[node] = low[next]
+				}
+			} else if onStack[next] && index[next] < low[node] {
+				low[node] = index[next]
+			}
+		}
+		if low[node] == index[node] {
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component[top] = count
+				if top == node {
+					break
+				}
+			}
+			count++
+		}
+	}
+	for _, node := range nodes {
+		if _, ok := index[node]; !ok {
+			visit(node)
+		}
+	}
+	return component
+}
+
+// Dot renders the graph in Graphviz DOT format.
+// Edges on a cycle are drawn blue, and edges on a left-recursive cycle are drawn red,
+// along with the rules they connect.
+func (g *Graph) Dot() string {
+	recursive := map[reflect.Type]bool{}
+	leftRecursive := map[reflect.Type]bool{}
+	for _, edge := range g.Edges {
+		if edge.Recursive {
+			recursive[edge.From] = true
+			recursive[edge.To] = true
+		}
+		if edge.LeftRecursive {
+			leftRecursive[edge.From] = true
+			leftRecursive[edge.To] = true
+		}
+	}
+	// Nodes are identified by their index, since types from different packages may share a name.
+	ids := map[reflect.Type]string{}
+	for i, node := range g.Nodes {
+		ids[node] = fmt.Sprintf("n%d", i)
+	}
+	var out bytes.Buffer
+	out.WriteString("digraph grammar {\n")
+	out.WriteString("\tnode [shape=box];\n")
+	for _, node := range g.Nodes {
+		attributes := []string{fmt.Sprintf("label=%q", node.String())}
+		if node == g.Root {
+			attributes = append(attributes, "peripheries=2")
+		}
+		if isAlternation(node) || node.Kind() == reflect.Interface {
+			attributes = append(attributes, "style=rounded")
+		}
+		if leftRecursive[node] {
+			attributes = append(attributes, "color=red", "fontcolor=red")
+		} else if recursive[node] {
+			attributes = append(attributes, "color=blue")
+		}
+		fmt.Fprintf(&out, "\t%s [%s];\n", ids[node], strings.Join(attributes, ", "))
+	}
+	for _, edge := range g.Edges {
+		kinds := make([]string, len(edge.Kinds))
+		for i, kind := range edge.Kinds {
+			kinds[i] = string(kind)
+		}
+		attributes := []string{fmt.Sprintf("label=%q", edge.Field+"\n"+strings.Join(kinds, " "))}
+		if edge.LeftRecursive {
+			attributes = append(attributes, "color=red", "fontcolor=red", "penwidth=2")
+		} else if edge.Recursive {
+			attributes = append(attributes, "color=blue")
+		}
+		if edge.Kinds[len(edge.Kinds)-1] == EdgeNegative || edge.Kinds[len(edge.Kinds)-1] == EdgeLookahead {
+			attributes = append(attributes, "style=dashed")
+		}
+		fmt.Fprintf(&out, "\t%s -> %s [%s];\n", ids[edge.From], ids[edge.To], strings.Join(attributes, ", "))
+	}
+	out.WriteString("}\n")
+	return out.String()
+}
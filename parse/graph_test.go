@@ -0,0 +1,75 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+type graphList struct {
+	Open  Literal `parse:"("`
+	Items []graphItem
+	Close Literal `parse:")"`
+}
+
+type graphItem struct {
+	Choice `name:"item"`
+	List   graphList
+	Atom   Literal `parse:"a"`
+}
+
+type graphLeft struct {
+	Location Location
+	Again    *graphLeft
+	Atom     Literal `parse:"a"`
+}
+
+func TestGraphRecursion(t *testing.T) {
+	graph := GraphOf(new(graphList))
+	if len(graph.Nodes) != 2 || len(graph.Edges) != 2 {
+		t.Fatalf("unexpected graph %+v", graph)
+	}
+	for _, edge := range graph.Edges {
+		if !edge.Recursive {
+			t.Errorf("expected %s to be recursive", edge.Field)
+		}
+		if edge.LeftRecursive {
+			t.Errorf("expected %s not to be left-recursive", edge.Field)
+		}
+	}
+	if edge := graph.Edges[0]; edge.Field != "Items" || len(edge.Kinds) != 2 || edge.Kinds[1] != EdgeMany {
+		t.Errorf("unexpected edge %+v", edge)
+	}
+	if edge := graph.Edges[1]; edge.Field != "List" || edge.Kinds[0] != EdgeChoice {
+		t.Errorf("unexpected edge %+v", edge)
+	}
+}
+
+func TestGraphLeftRecursion(t *testing.T) {
+	graph := GraphOf(new(graphLeft))
+	if len(graph.Edges) != 1 || !graph.Edges[0].LeftRecursive {
+		t.Fatalf("expected left recursion through a nullable Location: %+v", graph.Edges)
+	}
+	if !strings.Contains(graph.Dot(), `label="Again\nsequence optional", color=red`) {
+		t.Errorf("unexpected dot output:\n%s", graph.Dot())
+	}
+}
+
+func TestGraphDotNamesakes(t *testing.T) {
+	type namesake struct {
+		Atom Literal `parse:"a"`
+	}
+	type inner = namesake
+	{
+		type namesake struct {
+			Inner inner
+			Atom  Literal `parse:"b"`
+		}
+		graph := GraphOf(new(namesake))
+		if graph.Nodes[0].String() != graph.Nodes[1].String() {
+			t.Fatalf("expected types with the same name: %v", graph.Nodes)
+		}
+		if dot := graph.Dot(); !strings.Contains(dot, "n0 -> n1") {
+			t.Errorf("expected types with the same name to be distinct nodes:\n%s", dot)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package parse
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// WithFS gives the file system that fields tagged with `include` read files from.
+// Name the top-level source with WithFile, so that it can take part in include cycles.
+func WithFS(fsys fs.FS) Option {
+	return func(state *State) {
+		state.FS = fsys
+	}
+}
+
+// parseInclude parses a field tagged `include:"<field>"` from the file named by the text of
+// the earlier field, instead of from the current source. Quoted names are unquoted, and
+// names are relative to the directory of the including file.
+// The included file is parsed with the same options, and must be parsed completely.
+// Once the name has been parsed there is no backtracking, so failures are fatal.
+func parseInclude(state *State, value reflect.Value, field reflect.StructField) interface{} {
+	nameField := value.FieldByName(field.Tag.Get("include"))
+	if !nameField.IsValid() {
+		panic(fmt.Sprintf("include field %s refers to missing field %q", field.Name, field.Tag.Get("include")))
+	}
+	name := strings.TrimSpace(string(Text(nameField.Interface())))
+	if unquoted, err := strconv.Unquote(name); err == nil {
+		name = unquoted
+	}
+	if state.FS == nil {
+		includeFailed(state.Errorf("cannot include %q without a file system (use WithFS)", name))
+	}
+	filename := path.Join(path.Dir(state.Filename), name)
+	chain := append(append([]string{}, state.includes...), state.Filename)
+	for i, each := range chain {
+		if each == filename {
+			includeFailed(state.Errorf("include cycle: %s", strings.Join(append(chain[i:], filename), " -> ")))
+		}
+	}
+	source, err := fs.ReadFile(state.FS, filename)
+	if err != nil {
+		includeFailed(state.Errorf("cannot include %q: %s", name, err.Error()))
+	}
+	// The included file is parsed with a copy of the options, and its own source.
+	inner := *state
+	inner.Source = source
+	inner.Position = 0
+	inner.Memory = map[Input]Output{}
+	inner.Learning = map[Input]bool{}
+	inner.Filename = filename
+	inner.lexical = false
+	inner.includes = chain
+	inner.File = nil
+	inner.shifts = nil
+	inner.coverageLog = nil
+	inner.prepare()
+	// The file is required even for an optional field, so its contents are too; parsing
+	// them as optional would hide where they fail.
+	into := field.Type
+	if into.Kind() == reflect.Ptr {
+		into = into.Elem()
+	}
+	result, err := parseIntoTypeCapture(&inner, into)
+	if err != nil {
+		if _, ok := err.(*Error); !ok {
+			err = inner.Errorf("%s", err.Error())
+		}
+		includeFailed(err)
+	}
+	if inner.Position != len(inner.Source) {
+		includeFailed(inner.Errorf("expected end of included file"))
+	}
+	state.coverageLog = append(state.coverageLog, inner.coverageLog...)
+	if into != field.Type {
+		pointer := reflect.New(into)
+		pointer.Elem().Set(reflect.ValueOf(result))
+		return pointer.Interface()
+	}
+	return result
+}
+
+// includeFailed stops parsing with an error, which keeps its own position.
+func includeFailed(err error) {
+	positioned := err.(*Error)
+	panic(fatalError{
+		Location: positioned.Position,
+		Message:  positioned.Message,
+	})
+}
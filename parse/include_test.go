@@ -0,0 +1,79 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type includeConfig struct {
+	Entries []includeEntry
+}
+
+type includeEntry struct {
+	Choice  `name:"entry"`
+	Include includeDirective
+	Setting includeSetting
+}
+
+type includeDirective struct {
+	Keyword Literal        `parse:"include "`
+	Path    Regex          `regex:"\"[^\"]*\""`
+	Newline Literal        `parse:"\n"`
+	Config  *includeConfig `include:"Path"`
+}
+
+type includeOptional struct {
+	Keyword Literal         `parse:"include "`
+	Path    Regex           `regex:"[a-z.]+"`
+	Setting *includeSetting `include:"Path"`
+}
+
+type includeSetting struct {
+	Name    Regex   `regex:"[a-z]+"`
+	Newline Literal `parse:"\n"`
+}
+
+func TestInclude(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.cfg":       {Data: []byte("a\ninclude \"sub/other.cfg\"\nb\n")},
+		"sub/other.cfg":  {Data: []byte("c\ninclude \"deeper.cfg\"\n")},
+		"sub/deeper.cfg": {Data: []byte("d\n")},
+		"cycle.cfg":      {Data: []byte("include \"sub/cycle.cfg\"\n")},
+		"sub/cycle.cfg":  {Data: []byte("include \"../cycle.cfg\"\n")},
+		"broken.cfg":     {Data: []byte("include \"sub/broken.cfg\"\n")},
+		"sub/broken.cfg": {Data: []byte("e\n!\n")},
+		"setting.cfg":    {Data: []byte("f!\n")},
+	}
+	var result includeConfig
+	set := NewFileSet()
+	if err := Parse(string(fsys["main.cfg"].Data), &result, WithFS(fsys), WithFile(set, "main.cfg")); err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	other := result.Entries[1].Include.Config
+	if other == nil || len(other.Entries) != 2 {
+		t.Fatalf("unexpected included config %+v", other)
+	}
+	deeper := other.Entries[1].Include.Config.Entries[0].Setting.Name
+	if start := deeper.Span.Start.String(); start != "sub/deeper.cfg:1:1" {
+		t.Errorf("unexpected position %s", start)
+	}
+	if text := string(Text(result.Entries[1].Include)); text != "include \"sub/other.cfg\"\n" {
+		t.Errorf("expected the included file to be left out of the text, but got %q", text)
+	}
+
+	err := Parse(string(fsys["cycle.cfg"].Data), &result, WithFS(fsys), WithFile(set, "cycle.cfg"))
+	if err == nil || !strings.Contains(err.Error(), "include cycle: cycle.cfg -> sub/cycle.cfg -> cycle.cfg") {
+		t.Errorf("expected an include cycle but got %v", err)
+	}
+	var directive includeDirective
+	err = Parse(string(fsys["broken.cfg"].Data), &directive, WithFS(fsys), WithFile(set, "broken.cfg"))
+	if err == nil || !strings.Contains(err.Error(), "at sub/broken.cfg:2:1") {
+		t.Errorf("expected an error in the included file but got %v", err)
+	}
+	var optional includeOptional
+	err = Parse("include setting.cfg", &optional, WithFS(fsys), WithFile(set, "optional.cfg"))
+	if err == nil || !strings.Contains(err.Error(), "at setting.cfg:1:2") {
+		t.Errorf("expected an error inside the optional included file but got %v", err)
+	}
+}
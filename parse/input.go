@@ -0,0 +1,162 @@
+package parse
+
+import (
+	"bytes"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var byteOrderMark = []byte{0xEF, 0xBB, 0xBF}
+
+// SkipBOM skips a leading UTF-8 byte order mark, so that the grammar never sees it.
+// Positions still count offsets in the original source, but the mark takes up no column.
+func SkipBOM() Option {
+	return func(state *State) {
+		state.SkipBOM = true
+	}
+}
+
+// CountLineEndings counts "\r\n", "\r" and "\n" each as a single line break in positions.
+// The grammar still sees the source unchanged.
+func CountLineEndings() Option {
+	return func(state *State) {
+		state.LineEndings = true
+	}
+}
+
+// NormalizeLineEndings turns "\r\n" and "\r" into "\n" before parsing, so that the grammar
+// only has to handle "\n". Positions (and the Text of spans) still refer to the original source.
+func NormalizeLineEndings() Option {
+	return func(state *State) {
+		state.LineEndings = true
+		state.normalize = true
+	}
+}
+
+// prepare registers the source, and then rewrites it as the options ask for.
+// Each byte removed from the source is recorded in shifts, as the first offset it moves,
+// so that positions can be mapped back to the original.
+func (s *State) prepare() {
+	if s.Encoding != EncodingUTF8 {
+		decoded, decodedStarts, encodedStarts := decode(s.Source, s.Encoding)
+		s.Source = decoded
+		s.file().decodedStarts = decodedStarts
+		s.File.encodedStarts = encodedStarts
+	}
+	s.file()
+	source := s.Source
+	rewritten := []byte{}
+	if s.SkipBOM && bytes.HasPrefix(source, byteOrderMark) {
+		for range byteOrderMark {
+			s.shifts = append(s.shifts, 0)
+		}
+		source = source[len(byteOrderMark):]
+	}
+	if !s.normalize {
+		if len(s.shifts) > 0 {
+			s.Source = source
+		}
+		return
+	}
+	for i := 0; i < len(source); i++ {
+		if source[i] != '\r' {
+			rewritten = append(rewritten, source[i])
+			continue
+		}
+		if i+1 < len(source) && source[i+1] == '\n' {
+			// The "\r" is dropped, but it's part of the "\n" that follows, so it only
+			// shifts the offsets after it.
+			s.shifts = append(s.shifts, len(rewritten)+1)
+			continue
+		}
+		rewritten = append(rewritten, '\n')
+	}
+	s.Source = rewritten
+}
+
+// An Encoding is the character encoding of a source.
+type Encoding int
+
+const (
+	// EncodingUTF8 parses the source as it is.
+	EncodingUTF8 Encoding = iota
+	// EncodingAuto detects UTF-16 from a byte order mark, and otherwise assumes UTF-8.
+	EncodingAuto
+	EncodingUTF16LE
+	EncodingUTF16BE
+	// EncodingLatin1 is ISO-8859-1, where each byte is the character with that code point.
+	EncodingLatin1
+)
+
+// WithEncoding decodes the source into UTF-8 before parsing. A UTF-16 byte order mark is
+// dropped. Positions count offsets, lines and columns in the decoded source, and also
+// give the EncodedOffset into the original bytes.
+func WithEncoding(encoding Encoding) Option {
+	return func(state *State) {
+		state.Encoding = encoding
+	}
+}
+
+var (
+	utf16LittleEndianMark = []byte{0xFF, 0xFE}
+	utf16BigEndianMark    = []byte{0xFE, 0xFF}
+)
+
+// decode converts the source into UTF-8, returning the decoded source along with
+// the offsets at which each character starts in the decoded and in the original source.
+func decode(source []byte, encoding Encoding) ([]byte, []int, []int) {
+	if encoding == EncodingAuto {
+		switch {
+		case bytes.HasPrefix(source, utf16LittleEndianMark):
+			encoding = EncodingUTF16LE
+		case bytes.HasPrefix(source, utf16BigEndianMark):
+			encoding = EncodingUTF16BE
+		default:
+			return source, nil, nil
+		}
+	}
+	decoded := []byte{}
+	decodedStarts := []int{}
+	encodedStarts := []int{}
+	for i := 0; i < len(source); {
+		r, size := rune(source[i]), 1
+		if encoding == EncodingUTF16LE || encoding == EncodingUTF16BE {
+			r, size = decodeUTF16(source[i:], encoding == EncodingUTF16BE)
+			if i == 0 && r == 0xFEFF {
+				i += size
+				continue
+			}
+		}
+		decodedStarts = append(decodedStarts, len(decoded))
+		encodedStarts = append(encodedStarts, i)
+		decoded = utf8.AppendRune(decoded, r)
+		i += size
+	}
+	decodedStarts = append(decodedStarts, len(decoded))
+	encodedStarts = append(encodedStarts, len(source))
+	return decoded, decodedStarts, encodedStarts
+}
+
+// decodeUTF16 decodes the first character of UTF-16 source, returning it and its size in bytes.
+// Malformed input decodes as utf8.RuneError.
+func decodeUTF16(source []byte, bigEndian bool) (rune, int) {
+	unit := func(i int) rune {
+		if bigEndian {
+			return rune(source[i])<<8 | rune(source[i+1])
+		}
+		return rune(source[i+1])<<8 | rune(source[i])
+	}
+	if len(source) < 2 {
+		return utf8.RuneError, len(source)
+	}
+	first := unit(0)
+	if !utf16.IsSurrogate(first) {
+		return first, 2
+	}
+	if len(source) >= 4 {
+		if r := utf16.DecodeRune(first, unit(2)); r != utf8.RuneError {
+			return r, 4
+		}
+	}
+	return utf8.RuneError, 2
+}
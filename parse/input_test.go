@@ -0,0 +1,80 @@
+package parse
+
+import "testing"
+
+func TestLineEndings(t *testing.T) {
+	source := "\xEF\xBB\xBFab\r\ncd\ref\n"
+	var counted fileExample
+	err := Parse(source, &counted, SkipBOM(), CountLineEndings())
+	if err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	if len(counted.Lines) != 0 {
+		t.Errorf("expected \\r\\n not to match \\n without normalization, but got %d lines", len(counted.Lines))
+	}
+
+	var normalized fileExample
+	err = Parse(source, &normalized, SkipBOM(), NormalizeLineEndings())
+	if err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	if len(normalized.Lines) != 3 {
+		t.Fatalf("expected 3 lines but got %d", len(normalized.Lines))
+	}
+	expected := []Position{
+		{Offset: 3, Line: 1, Column: 1, ByteColumn: 4},
+		{Offset: 7, Line: 2, Column: 1, ByteColumn: 1},
+		{Offset: 10, Line: 3, Column: 1, ByteColumn: 1},
+	}
+	for i, line := range normalized.Lines {
+		if start := line.Word.Span.Start; start != expected[i] {
+			t.Errorf("expected %+v but got %+v", expected[i], start)
+		}
+	}
+	if text := string(normalized.Lines[0].Newline.Span.Text()); text != "\r\n" {
+		t.Errorf("expected the original line ending but got %q", text)
+	}
+}
+
+type encodingExample struct {
+	Words []encodingWord
+}
+
+type encodingWord struct {
+	Space Regex `regex:"\\s*"`
+	Word  Regex `regex:"\\pL+"`
+}
+
+func TestEncodings(t *testing.T) {
+	utf16 := []byte{0xFF, 0xFE, 'a', 0, ' ', 0, 0x3D, 0xD8, 0x00, 0xDE, 'b', 0}
+	var decoded encodingExample
+	if err := Parse(string(utf16), &decoded, WithEncoding(EncodingAuto)); err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	if len(decoded.Words) != 1 {
+		t.Fatalf("expected the emoji to stop the words, but got %+v", decoded.Words)
+	}
+	if end := decoded.Words[0].Word.Span.End; end.EncodedOffset != 4 || end.Offset != 1 {
+		t.Errorf("unexpected end position %+v", end)
+	}
+
+	latin1 := []byte{'c', 'a', 'f', 0xE9, ' ', 'b', 'a', 'r'}
+	var words encodingExample
+	if err := Parse(string(latin1), &words, WithEncoding(EncodingLatin1)); err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	if len(words.Words) != 2 || string(words.Words[0].Word.Contents) != "café" {
+		t.Fatalf("unexpected words %+v", words.Words)
+	}
+	if start := words.Words[1].Word.Span.Start; start.EncodedOffset != 5 || start.Offset != 6 {
+		t.Errorf("unexpected start position %+v", start)
+	}
+
+	var plain encodingExample
+	if err := Parse("café bar", &plain); err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	if span := plain.Words[1].Word.Span; span.File.EncodedOffset(span.Start.Offset) != 6 {
+		t.Errorf("expected an undecoded offset to be unchanged, but got %d", span.File.EncodedOffset(span.Start.Offset))
+	}
+}
@@ -0,0 +1,160 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Int is a signed integer, configured with tags:
+//
+//	bases:"10,16"   the bases allowed; other than 10, each needs its prefix (0x, 0o or 0b). Default "10".
+//	separator:"_"   characters allowed between digits, as in 1_000. Default none.
+//	sign:"+-"       the signs allowed in front of the number. Default "-".
+//	bits:"32"       the size of the target, which the value must fit. Default 64.
+//
+// An integer directly followed by a letter, digit or '.' (such as 1e3 or 1.5) is rejected.
+// A value that doesn't fit is a fatal error at the start of the integer.
+type Int struct {
+	Int  int64
+	Span Span
+}
+
+func (n *Int) ParseInto(state *State, tag reflect.StructTag) error {
+	digits, base, size, err := scanInteger(state, tag, "-")
+	if err != nil {
+		return err
+	}
+	value, err := strconv.ParseInt(digits, base, integerBits(tag))
+	if errors.Is(err, strconv.ErrRange) {
+		Panicf("integer %s does not fit in int%d", state.Rest()[:size], integerBits(tag))
+	}
+	if err != nil {
+		return state.Errorf("Expected integer but %s", err.Error())
+	}
+	n.Int = value
+	n.Span = state.Advance(size)
+	return nil
+}
+
+// Uint is an unsigned integer, configured with the same tags as Int, except that no sign
+// is allowed by default. A negative value is a fatal error.
+type Uint struct {
+	Uint uint64
+	Span Span
+}
+
+func (n *Uint) ParseInto(state *State, tag reflect.StructTag) error {
+	digits, base, size, err := scanInteger(state, tag, "")
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(digits, "-") {
+		if strings.Trim(digits, "-0") != "" {
+			Panicf("integer %s does not fit in uint%d", state.Rest()[:size], integerBits(tag))
+		}
+		digits = "0"
+	}
+	value, err := strconv.ParseUint(strings.TrimPrefix(digits, "+"), base, integerBits(tag))
+	if errors.Is(err, strconv.ErrRange) {
+		Panicf("integer %s does not fit in uint%d", state.Rest()[:size], integerBits(tag))
+	}
+	if err != nil {
+		return state.Errorf("Expected integer but %s", err.Error())
+	}
+	n.Uint = value
+	n.Span = state.Advance(size)
+	return nil
+}
+
+func integerBits(tag reflect.StructTag) int {
+	bits := tag.Get("bits")
+	if bits == "" {
+		return 64
+	}
+	size, err := strconv.Atoi(bits)
+	if err != nil || size <= 0 || size > 64 {
+		panic(fmt.Sprintf("integer given illegal 'bits' tag: %q", tag))
+	}
+	return size
+}
+
+var integerPrefixes = map[int]string{16: "0x", 8: "0o", 2: "0b"}
+
+// prefixedBases lists the keys of integerPrefixes, in the order they're tried.
+var prefixedBases = []int{16, 8, 2}
+
+// scanInteger finds the integer at the current position. It returns its sign and digits
+// with separators removed, its base, and the number of bytes it takes up in the source.
+func scanInteger(state *State, tag reflect.StructTag, defaultSigns string) (string, int, int, error) {
+	signs, ok := tag.Lookup("sign")
+	if !ok {
+		signs = defaultSigns
+	}
+	bases := map[int]bool{}
+	for _, each := range strings.Split(tag.Get("bases"), ",") {
+		if each == "" {
+			each = "10"
+		}
+		base, err := strconv.Atoi(strings.TrimSpace(each))
+		if err != nil || (base != 10 && integerPrefixes[base] == "") {
+			panic(fmt.Sprintf("integer given illegal 'bases' tag: %q", tag))
+		}
+		bases[base] = true
+	}
+	separators := tag.Get("separator")
+	rest := state.Rest()
+	i := 0
+	sign := ""
+	if i < len(rest) && (rest[i] == '-' || rest[i] == '+') && strings.IndexByte(signs, rest[i]) >= 0 {
+		sign = string(rest[i])
+		i++
+	}
+	base := 10
+	for _, prefixBase := range prefixedBases {
+		if bases[prefixBase] && len(rest) >= i+2 && strings.EqualFold(string(rest[i:i+2]), integerPrefixes[prefixBase]) {
+			base = prefixBase
+			i += 2
+			break
+		}
+	}
+	if base == 10 && !bases[10] {
+		return "", 0, 0, state.Errorf("Expected integer")
+	}
+	digits := []byte(sign)
+	for ; i < len(rest); i++ {
+		if digitValue(rest[i]) < base {
+			digits = append(digits, rest[i])
+			continue
+		}
+		if strings.IndexByte(separators, rest[i]) < 0 {
+			break
+		}
+		// A separator must come between two digits (or just after a prefix, as in 0x_ff).
+		if i+1 >= len(rest) || digitValue(rest[i+1]) >= base || len(digits) == len(sign) && base == 10 {
+			return "", 0, 0, state.Errorf("Expected digit after %q in integer", rest[i])
+		}
+	}
+	if len(digits) == len(sign) {
+		return "", 0, 0, state.Errorf("Expected integer")
+	}
+	if i < len(rest) && (rest[i] == '.' || digitValue(rest[i]) < 36 || rest[i] == '_') {
+		return "", 0, 0, state.Errorf("Expected integer but found %q after it", rest[i])
+	}
+	return string(digits), base, i, nil
+}
+
+// digitValue is the value of a digit in bases up to 36, or 36 if it isn't one.
+func digitValue(c byte) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0')
+	case 'a' <= c && c <= 'z':
+		return int(c-'a') + 10
+	case 'A' <= c && c <= 'Z':
+		return int(c-'A') + 10
+	}
+	return 36
+}
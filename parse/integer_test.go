@@ -0,0 +1,72 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+type integerList struct {
+	Items []integerItem
+}
+
+type integerItem struct {
+	Value Int     `bases:"10,16,8,2" separator:"_" sign:"+-" bits:"16"`
+	Space Literal `parse:" "`
+}
+
+type hexOrBinary struct {
+	Value Int `bases:"16,2"`
+}
+
+type unsignedByte struct {
+	Value Uint `bits:"8"`
+}
+
+func TestInteger(t *testing.T) {
+	list := integerList{}
+	if err := Parse("1_000 -0x_7f +0o17 0B101 -32768 ", &list); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := []int64{1000, -0x7f, 0o17, 5, -32768}
+	if len(list.Items) != len(want) {
+		t.Fatalf("expected %d integers but got %d", len(want), len(list.Items))
+	}
+	for i, item := range list.Items {
+		if item.Value.Int != want[i] {
+			t.Errorf("integer %d: expected %d but got %d", i, want[i], item.Value.Int)
+		}
+	}
+	if got := string(list.Items[1].Value.Span.Text()); got != "-0x_7f" {
+		t.Errorf("expected span text %q but got %q", "-0x_7f", got)
+	}
+
+	for _, source := range []string{"1__0 ", "_1 ", "1_ ", "1e3 ", "1.5 ", "0x ", "- "} {
+		if err := Parse(source, &integerItem{}); err == nil || !strings.Contains(err.Error(), "Expected") {
+			t.Errorf("%q: expected a parse error but got %v", source, err)
+		}
+	}
+
+	err := Parse("1 2 32768 ", &integerList{})
+	if err == nil || err.Error() != "integer 32768 does not fit in int16 at 1:5" {
+		t.Errorf("expected overflow error but got %v", err)
+	}
+
+	// Only one prefix is read, so the 0b here is hexadecimal digits.
+	for i := 0; i < 20; i++ {
+		h := hexOrBinary{}
+		if err := Parse("0x0b1", &h); err != nil || h.Value.Int != 0xb1 {
+			t.Fatalf("expected 0xb1 but got %d (%v)", h.Value.Int, err)
+		}
+	}
+
+	b := unsignedByte{}
+	if err := Parse("255", &b); err != nil || b.Value.Uint != 255 {
+		t.Errorf("expected 255 but got %d (%v)", b.Value.Uint, err)
+	}
+	if err := Parse("256", &b); err == nil || err.Error() != "integer 256 does not fit in uint8 at 1:1" {
+		t.Errorf("expected overflow error but got %v", err)
+	}
+	if err := Parse("-1", &b); err == nil {
+		t.Errorf("expected -1 to be rejected")
+	}
+}
@@ -0,0 +1,165 @@
+package parse
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+// identifierStart and identifierContinue are the classes of characters that identifiers
+// start and continue with, unless changed with the 'start' and 'continue' tags.
+const (
+	identifierStart    = `\p{L}_`
+	identifierContinue = `\p{L}\p{Nd}_`
+)
+
+// Keyword is annotated with parse:"<keyword>", like Literal, but doesn't match when the
+// keyword is directly followed by a character that could continue an identifier,
+// so that parse:"if" doesn't match the start of "iffy".
+// The tag continue:"<class>" changes those characters, in the syntax of Class, and
+// case:"fold" ignores case as for Literal.
+// Every keyword in a grammar is reserved, so that an Identifier won't match it.
+type Keyword struct {
+	Contents []byte
+	Span     Span
+}
+
+func (k *Keyword) ParseInto(state *State, tag reflect.StructTag) error {
+	keyword := tag.Get("parse")
+	if keyword == "" {
+		panic(fmt.Sprintf("parse.Keyword given illegal no 'parse' tag: %q", tag))
+	}
+	rest := state.Rest()
+	size, ok := matchLiteral(rest, keyword, tag)
+	if !ok {
+		return state.Errorf("Expected %q", keyword)
+	}
+	if r, _ := utf8.DecodeRune(rest[size:]); len(rest) > size && identifierClass(tag, "continue", identifierContinue).matches(r) {
+		return state.Errorf("Expected %q but found %q", keyword, identifierAt(rest, tag))
+	}
+	k.Contents = rest[:size]
+	k.Span = state.Advance(size)
+	return nil
+}
+
+// Identifier is a name that isn't reserved. It starts with a letter or '_' and continues
+// with letters, digits and '_', unless changed with the tags start:"<class>" and
+// continue:"<class>", in the syntax of Class.
+// The reserved words are the Keywords of the grammar being parsed, along with those
+// given to WithReserved.
+type Identifier struct {
+	Contents []byte
+	Span     Span
+}
+
+func (i *Identifier) ParseInto(state *State, tag reflect.StructTag) error {
+	rest := state.Rest()
+	r, _ := utf8.DecodeRune(rest)
+	if len(rest) == 0 || !identifierClass(tag, "start", identifierStart).matches(r) {
+		return state.Errorf("Expected identifier")
+	}
+	name := identifierAt(rest, tag)
+	if state.isReserved(name) {
+		return state.Errorf("Expected identifier but found keyword %q", name)
+	}
+	i.Contents = rest[:len(name)]
+	i.Span = state.Advance(len(name))
+	return nil
+}
+
+// identifierAt finds the identifier characters at the start of text.
+func identifierAt(text []byte, tag reflect.StructTag) string {
+	_, size := utf8.DecodeRune(text)
+	continues := identifierClass(tag, "continue", identifierContinue)
+	for size < len(text) {
+		r, width := utf8.DecodeRune(text[size:])
+		if !continues.matches(r) {
+			break
+		}
+		size += width
+	}
+	return string(text[:size])
+}
+
+func identifierClass(tag reflect.StructTag, key string, fallback string) *characterClass {
+	if source, ok := tag.Lookup(key); ok {
+		return compileClass(source)
+	}
+	return compileClass(fallback)
+}
+
+// isReserved reports whether an identifier is one of the reserved words.
+func (s *State) isReserved(name string) bool {
+	return s.Reserved[name] || s.FoldedReserved[foldCase(name)]
+}
+
+// WithReserved reserves words besides the grammar's keywords, so that an Identifier won't match them.
+func WithReserved(words ...string) Option {
+	return func(state *State) {
+		reserved := map[string]bool{}
+		for word := range state.Reserved {
+			reserved[word] = true
+		}
+		for _, word := range words {
+			reserved[word] = true
+		}
+		state.Reserved = reserved
+	}
+}
+
+// grammarKeywords caches the keywords of each grammar by its root type.
+var grammarKeywords sync.Map
+
+// A keywordSet holds the keywords of a grammar: those reserved as written, and those
+// reserved in any case, as spelled by foldCase. words lists every keyword as written.
+type keywordSet struct {
+	exact  map[string]bool
+	folded map[string]bool
+	words  []string
+}
+
+// Keywords lists the spellings of every Keyword in the grammar rooted at the type pointed to by target.
+func Keywords(target interface{}) []string {
+	return append([]string{}, keywordsOf(reflect.TypeOf(target).Elem()).words...)
+}
+
+// keywordsOf finds the keywords of the grammar rooted at a type, caching them for each root.
+func keywordsOf(root reflect.Type) *keywordSet {
+	if keywords, ok := grammarKeywords.Load(root); ok {
+		return keywords.(*keywordSet)
+	}
+	keywords := &keywordSet{exact: map[string]bool{}, folded: map[string]bool{}, words: []string{}}
+	seen := map[string]bool{}
+	if rule := ruleOf(root); rule != nil {
+		for _, node := range GraphOf(reflect.New(rule).Interface()).Nodes {
+			for f := 0; f < node.NumField(); f++ {
+				field := node.Field(f)
+				into := field.Type
+				for !isLeaf(into) {
+					if _, ok := wrapperKind(into); !ok {
+						break
+					}
+					into = into.Elem()
+				}
+				if into != reflect.TypeOf(Keyword{}) {
+					continue
+				}
+				word := field.Tag.Get("parse")
+				if field.Tag.Get("case") == "fold" {
+					keywords.folded[foldCase(word)] = true
+				} else {
+					keywords.exact[word] = true
+				}
+				if !seen[word] {
+					seen[word] = true
+					keywords.words = append(keywords.words, word)
+				}
+			}
+		}
+	}
+	sort.Strings(keywords.words)
+	grammarKeywords.Store(root, keywords)
+	return keywords
+}
@@ -0,0 +1,54 @@
+package parse
+
+import (
+	"reflect"
+	"testing"
+)
+
+type keywordStatement struct {
+	Choice `name:"statement"`
+	If     keywordIf
+	Assign keywordAssign
+}
+
+type keywordIf struct {
+	If        Keyword `parse:"if"`
+	Space     Literal `parse:" "`
+	Condition Identifier
+}
+
+type keywordAssign struct {
+	Name   Identifier `continue:"\\p{L}\\p{Nd}_-"`
+	Equals Literal    `parse:"="`
+	Value  Identifier
+}
+
+func TestKeyword(t *testing.T) {
+	statement := keywordStatement{}
+	if err := Parse("iffy=x", &statement); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if statement.Choice.Index != 2 || string(statement.Assign.Name.Contents) != "iffy" {
+		t.Errorf("expected iffy to be an identifier, got %+v", statement)
+	}
+	if err := Parse("if ready", &statement); err != nil || string(statement.If.Condition.Contents) != "ready" {
+		t.Errorf("expected an if statement, got %+v (%v)", statement, err)
+	}
+	if err := Parse("my-name=x", &statement); err != nil || string(statement.Assign.Name.Contents) != "my-name" {
+		t.Errorf("expected the continue tag to allow '-', got %+v (%v)", statement, err)
+	}
+
+	if err := Parse("if if", &statement); err == nil {
+		t.Errorf("expected the keyword to be reserved")
+	}
+	if err := Parse("x=y", &statement, WithReserved("y")); err == nil {
+		t.Errorf("expected y to be reserved")
+	}
+	if err := Parse("x=y", &statement); err != nil {
+		t.Errorf("expected WithReserved to only apply to its own call, got %v", err)
+	}
+
+	if got := Keywords(&keywordStatement{}); !reflect.DeepEqual(got, []string{"if"}) {
+		t.Errorf("expected keywords [if] but got %q", got)
+	}
+}
@@ -0,0 +1,193 @@
+package parse
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// A PathStep is one node on the way from a parsed root down to the node found by NodeAt.
+// Path is the field path from the root, such as "Arguments[1].Call".
+type PathStep struct {
+	Path  string
+	Value reflect.Value
+	Span  Span
+}
+
+// NodeAt finds the innermost node of a parsed value whose span contains the byte offset,
+// along with every node enclosing it, starting with root itself.
+// Spans come from the built-in leaves and from struct fields of type Span; structs
+// without one span the nodes inside them.
+// Only the chosen field of an alternation, non-nil optionals and the elements of
+// repetitions are searched. If root does not contain the offset, the result is empty.
+func NodeAt(root interface{}, offset int) []PathStep {
+	value := reflect.ValueOf(root)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	span, ok := spanOf(value)
+	if !ok || !spanContains(span, offset) {
+		return nil
+	}
+	steps := []PathStep{{Path: "", Value: value, Span: span}}
+	for {
+		child, ok := childAt(steps[len(steps)-1], offset)
+		if !ok {
+			return steps
+		}
+		steps = append(steps, child)
+	}
+}
+
+func spanContains(span Span, offset int) bool {
+	return span.Start.Offset <= offset && offset < span.End.Offset
+}
+
+// childAt finds the child of a node whose span contains the offset.
+func childAt(parent PathStep, offset int) (PathStep, bool) {
+	found := PathStep{}
+	ok := false
+	eachChild(parent.Value, parent.Path, func(path string, child reflect.Value) bool {
+		span, has := spanOf(child)
+		if has && sameFile(span, parent.Span) && spanContains(span, offset) {
+			found = PathStep{Path: path, Value: child, Span: span}
+			ok = true
+			return false
+		}
+		return true
+	})
+	return found, ok
+}
+
+// eachChild calls visit with each parsed node directly inside of value, until visit returns false.
+// Optionals are followed, and each element of a repetition is its own child.
+func eachChild(value reflect.Value, path string, visit func(path string, child reflect.Value) bool) bool {
+	if value.Kind() != reflect.Struct || isLeaf(value.Type()) {
+		return true
+	}
+	into := value.Type()
+	for i := 0; i < into.NumField(); i++ {
+		if !isGrammarField(into, i) {
+			continue
+		}
+		if isAlternation(into) && value.Field(0).Interface().(Choice).Index != i {
+			continue
+		}
+		name := into.Field(i).Name
+		if path != "" {
+			name = path + "." + name
+		}
+		if !eachValue(value.Field(i), name, visit) {
+			return false
+		}
+	}
+	return true
+}
+
+// eachValue visits a field's value, looking through optionals and repetitions.
+func eachValue(value reflect.Value, path string, visit func(path string, child reflect.Value) bool) bool {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return true
+		}
+		return eachValue(value.Elem(), path, visit)
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			if !eachValue(value.Index(i), fmt.Sprintf("%s[%d]", path, i), visit) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		return visit(path, value)
+	}
+	// Lookaheads don't consume anything, so they have no place in the tree.
+	return true
+}
+
+// spanOf finds the span of a parsed value.
+func spanOf(value reflect.Value) (Span, bool) {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return Span{}, false
+		}
+		return spanOf(value.Elem())
+	case reflect.Slice:
+		return unionSpans(func(visit func(string, reflect.Value) bool) {
+			eachValue(value, "", visit)
+		})
+	case reflect.Struct:
+	default:
+		return Span{}, false
+	}
+	if location, ok := value.Interface().(Location); ok {
+		return Span{Start: location.Position, End: location.Position}, true
+	}
+	if span, ok := value.Interface().(Span); ok {
+		return span, span != Span{}
+	}
+	into := value.Type()
+	for i := 0; i < into.NumField(); i++ {
+		if into.Field(i).Type == spanType && (isSpanField(into.Field(i)) || isLeaf(into)) {
+			span := value.Field(i).Interface().(Span)
+			return span, span != Span{}
+		}
+	}
+	if isLeaf(into) {
+		return Span{}, false
+	}
+	return unionSpans(func(visit func(string, reflect.Value) bool) {
+		if !eachChild(value, "", visit) {
+			return
+		}
+		// Fields such as oneof operators have no span of their own, but may record one.
+		for i := 0; i < into.NumField(); i++ {
+			if _, ok := spannedField(into.Field(i)); ok && !visit(into.Field(i).Name, value.Field(i)) {
+				return
+			}
+		}
+	})
+}
+
+// sameFile reports whether two spans can be compared. Spans without a File, such as those
+// of a Location, belong to any file.
+func sameFile(a, b Span) bool {
+	return a.File == nil || b.File == nil || a.File == b.File
+}
+
+// Text returns the exact source that a parsed node was parsed from, including any
+// spacing and comments inside of it. node may be a value or a pointer to one.
+// It returns nil if the node has no span, for example because it is empty.
+func Text(node interface{}) []byte {
+	span, ok := spanOf(reflect.ValueOf(node))
+	if !ok {
+		return nil
+	}
+	return span.Text()
+}
+
+// unionSpans finds the smallest span covering all of the children in the same file as the
+// first of them. Children included from other files have offsets into those files instead.
+func unionSpans(children func(visit func(string, reflect.Value) bool)) (Span, bool) {
+	union := Span{}
+	found := false
+	children(func(_ string, child reflect.Value) bool {
+		span, ok := spanOf(child)
+		if !ok || !sameFile(span, union) {
+			return true
+		}
+		if !found || span.Start.Offset < union.Start.Offset {
+			union.Start = span.Start
+		}
+		if union.File == nil {
+			union.File = span.File
+		}
+		if !found || span.End.Offset > union.End.Offset {
+			union.End = span.End
+		}
+		found = true
+		return true
+	})
+	return union, found
+}
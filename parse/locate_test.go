@@ -0,0 +1,62 @@
+package parse
+
+import "testing"
+
+func TestNodeAt(t *testing.T) {
+	var result spanCall
+	if err := Parse("f(1g(2))", &result); err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	steps := NodeAt(&result, 5)
+	expected := []string{
+		"",
+		"Arguments[1]",
+		"Arguments[1].Call",
+		"Arguments[1].Call.Arguments[0]",
+		"Arguments[1].Call.Arguments[0].Number",
+	}
+	if len(steps) != len(expected) {
+		t.Fatalf("expected %d steps but got %+v", len(expected), steps)
+	}
+	for i, step := range steps {
+		if step.Path != expected[i] {
+			t.Errorf("expected path %q but got %q", expected[i], step.Path)
+		}
+	}
+	if number, ok := steps[4].Value.Interface().(Number); !ok || number.Number != 2 {
+		t.Errorf("unexpected innermost node %+v", steps[4].Value)
+	}
+	if steps := NodeAt(&result, 8); len(steps) != 0 {
+		t.Errorf("expected nothing past the end but got %+v", steps)
+	}
+}
+
+func TestNodeAtWithoutSpans(t *testing.T) {
+	var result graphList
+	if err := Parse("(a(a))", &result); err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	steps := NodeAt(result, 3)
+	if len(steps) != 5 || steps[4].Path != "Items[1].List.Items[0].Atom" {
+		t.Fatalf("unexpected steps %+v", steps)
+	}
+	if span := steps[2].Span; span.Start.Offset != 2 || span.End.Offset != 5 {
+		t.Errorf("expected the span of a struct to cover its fields, but got %s", span)
+	}
+}
+
+func TestText(t *testing.T) {
+	var result positionExample
+	if err := Parse("ab\n\tcd  ef", &result); err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	if text := string(Text(&result)); text != "ab\n\tcd  ef" {
+		t.Errorf("unexpected text %q", text)
+	}
+	if text := string(Text(result.Words[1:])); text != "\n\tcd  ef" {
+		t.Errorf("unexpected text %q", text)
+	}
+	if text := string(Text(result.Words[2].Word)); text != "ef" {
+		t.Errorf("unexpected text %q", text)
+	}
+}
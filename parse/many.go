@@ -6,10 +6,15 @@ import "reflect"
 func parseMany(state *State, into reflect.Type, tag reflect.StructTag) (interface{}, error) {
 	slice := reflect.Zero(reflect.SliceOf(into))
 	for {
+		oldPosition := state.Position
 		value, err := parseIntoType(state, into, tag)
 		if err != nil {
 			break
 		}
+		if state.Position == oldPosition {
+			// An empty match would repeat forever.
+			break
+		}
 		slice = reflect.Append(slice, reflect.ValueOf(value))
 	}
 	return slice.Interface(), nil
@@ -0,0 +1,183 @@
+package parse
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Minimize shrinks input to a smaller string for which interesting still returns true,
+// using ddmin-style deletion, first of whole lines and then of single characters.
+// interesting(input) must be true.
+func Minimize(input string, interesting func(string) bool) string {
+	input = ddmin(splitLines(input), interesting)
+	return ddmin(splitRunes(input), interesting)
+}
+
+// MinimizeGrammar is like Minimize, but first tries deleting the pieces of input that
+// parse as a rule of the grammar of target, and replacing rules with smaller nested
+// copies of themselves. This tends to find much smaller inputs, since the pieces it
+// removes are ones the grammar can do without.
+// target should be a pointer, as for Parse; it is only used for its type. The options are
+// used to parse input, and should match those interesting parses with.
+func MinimizeGrammar(input string, target interface{}, interesting func(string) bool, options ...Option) string {
+	pointer := reflect.TypeOf(target)
+	if pointer == nil || pointer.Kind() != reflect.Ptr {
+		panic("MinimizeGrammar given non-pointer.")
+	}
+	for progress := true; progress; {
+		progress = false
+		for _, candidate := range grammarCandidates(input, pointer.Elem(), options) {
+			if len(candidate) < len(input) && interesting(candidate) {
+				input = candidate
+				progress = true
+				break
+			}
+		}
+	}
+	return Minimize(input, interesting)
+}
+
+// FailsWith makes a predicate that holds when parsing into the type of target returns an
+// error containing the given text. The options are passed to Parse.
+func FailsWith(target interface{}, text string, options ...Option) func(string) bool {
+	into := reflect.TypeOf(target).Elem()
+	return func(input string) bool {
+		err := Parse(input, reflect.New(into).Interface(), options...)
+		return err != nil && strings.Contains(err.Error(), text)
+	}
+}
+
+// Panics makes a predicate that holds when parsing into the type of target panics,
+// for example because a Verify method panics. The options are passed to Parse.
+func Panics(target interface{}, options ...Option) func(string) bool {
+	into := reflect.TypeOf(target).Elem()
+	return func(input string) (panicked bool) {
+		defer func() {
+			if recover() != nil {
+				panicked = true
+			}
+		}()
+		Parse(input, reflect.New(into).Interface(), options...)
+		return false
+	}
+}
+
+// parsedRange is a range of the source that parsed successfully as a type.
+type parsedRange struct {
+	start int
+	end   int
+	input Input
+	// order is when the range was parsed, which tells apart ranges of the same source.
+	order int
+}
+
+// grammarCandidates parses input and proposes smaller inputs, largest reductions first.
+func grammarCandidates(input string, into reflect.Type, options []Option) []string {
+	state := newState(input, into, options)
+	func() {
+		defer func() {
+			// The input is expected to misbehave; the ranges parsed until then are still useful.
+			recover()
+		}()
+		parseIntoTypeCapture(state, into)
+	}()
+	// Offsets into the prepared source are mapped back to input, which may have been
+	// decoded or normalized.
+	inputOffset := func(offset int) int {
+		return state.File.EncodedOffset(state.originalOffset(offset))
+	}
+	ranges := []parsedRange{}
+	for key, output := range state.Memory {
+		if output.Error == nil && output.Position > key.Position {
+			ranges = append(ranges, parsedRange{inputOffset(key.Position), inputOffset(output.Position), Input{Type: key.Type, Tag: key.Tag}, output.order})
+		}
+	}
+	// The memo is a map, so ranges are put in a full order for the result to be reproducible.
+	// Ranges that cover the same source are taken in the order they were parsed.
+	sort.Slice(ranges, func(i, j int) bool {
+		if ranges[i].end-ranges[i].start != ranges[j].end-ranges[j].start {
+			return ranges[i].end-ranges[i].start > ranges[j].end-ranges[j].start
+		}
+		if ranges[i].start != ranges[j].start {
+			return ranges[i].start < ranges[j].start
+		}
+		return ranges[i].order < ranges[j].order
+	})
+	candidates := []string{}
+	seen := map[string]bool{}
+	add := func(candidate string) {
+		if !seen[candidate] {
+			seen[candidate] = true
+			candidates = append(candidates, candidate)
+		}
+	}
+	for i, outer := range ranges {
+		add(input[:outer.start] + input[outer.end:])
+		// Hoisting a nested node of the same type keeps the result well-formed.
+		for _, inner := range ranges[i+1:] {
+			if inner.input == outer.input && outer.start <= inner.start && inner.end <= outer.end {
+				add(input[:outer.start] + input[inner.start:inner.end] + input[outer.end:])
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return len(candidates[i]) < len(candidates[j])
+	})
+	return candidates
+}
+
+func splitLines(input string) []string {
+	return strings.SplitAfter(input, "\n")
+}
+
+func splitRunes(input string) []string {
+	units := []string{}
+	for len(input) > 0 {
+		_, size := utf8.DecodeRuneInString(input)
+		units = append(units, input[:size])
+		input = input[size:]
+	}
+	return units
+}
+
+// ddmin finds a 1-minimal subsequence of units whose concatenation is interesting.
+func ddmin(units []string, interesting func(string) bool) string {
+	n := 2
+	for len(units) >= 2 {
+		size := (len(units) + n - 1) / n
+		reduced := false
+		for start := 0; start < len(units) && !reduced; start += size {
+			end := start + size
+			if end > len(units) {
+				end = len(units)
+			}
+			subset := units[start:end]
+			complement := append(append([]string{}, units[:start]...), units[end:]...)
+			if interesting(strings.Join(subset, "")) {
+				units = subset
+				n = 2
+				reduced = true
+			} else if n > 2 && interesting(strings.Join(complement, "")) {
+				units = complement
+				n--
+				reduced = true
+			}
+		}
+		if reduced {
+			continue
+		}
+		if n >= len(units) {
+			break
+		}
+		n *= 2
+		if n > len(units) {
+			n = len(units)
+		}
+	}
+	if len(units) == 1 && interesting("") {
+		return ""
+	}
+	return strings.Join(units, "")
+}
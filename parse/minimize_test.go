@@ -0,0 +1,93 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type minimizeList struct {
+	Open  Literal `parse:"["`
+	Items []minimizeItem
+	Close Literal `parse:"]"`
+}
+
+type minimizeItem struct {
+	Choice `name:"item"`
+	List   minimizeList
+	Digit  Regex `regex:"[0-9]"`
+	Bad    minimizeBad
+}
+
+type minimizeBad struct {
+	Bang Literal `parse:"!"`
+}
+
+func (b minimizeBad) Verify() error {
+	panic("bad item")
+}
+
+func TestMinimize(t *testing.T) {
+	interesting := func(input string) bool {
+		return strings.Contains(input, "x") && strings.Contains(input, "y")
+	}
+	if result := Minimize("abc\ndxe\nfgh\niyj\n", interesting); result != "xy" {
+		t.Errorf("expected minimal input %q but got %q", "xy", result)
+	}
+}
+
+func TestMinimizeGrammar(t *testing.T) {
+	input := "[1[2[3]4][5[6[7!]8]9]0]"
+	interesting := Panics(new(minimizeList))
+	if !interesting(input) {
+		t.Fatalf("expected %q to panic", input)
+	}
+	result := MinimizeGrammar(input, new(minimizeList), interesting)
+	if result != "[!" {
+		t.Errorf("expected minimal input %q but got %q", "[!", result)
+	}
+}
+
+func TestFailsWith(t *testing.T) {
+	var target minimizeList
+	interesting := FailsWith(&target, "Expected")
+	input := fmt.Sprintf("[%s", strings.Repeat("[1]", 20))
+	if !interesting(input) {
+		t.Fatalf("expected %q to fail", input)
+	}
+	if result := MinimizeGrammar(input, &target, interesting); len(result) > 1 {
+		t.Errorf("expected a small input but got %q", result)
+	}
+}
+
+func TestMinimizeGrammarOptions(t *testing.T) {
+	input := "\uFEFF[1[2[3]4][5!6]7]"
+	interesting := Panics(new(minimizeList), SkipBOM())
+	if !interesting(input) || Panics(new(minimizeList))(input) {
+		t.Fatalf("expected %q to panic only when skipping the byte order mark", input)
+	}
+	if result := MinimizeGrammar(input, new(minimizeList), interesting, SkipBOM()); result != "[!" {
+		t.Errorf("expected minimal input %q but got %q", "[!", result)
+	}
+}
+
+func TestMinimizeGrammarReproducible(t *testing.T) {
+	// Many inputs are as small as each other here, so the one found depends on the order
+	// that candidates are tried in.
+	interesting := func(input string) bool {
+		digits := 0
+		for _, r := range input {
+			if '0' <= r && r <= '9' {
+				digits++
+			}
+		}
+		return digits >= 2 && Parse(input, new(minimizeList)) == nil
+	}
+	input := "[1[2[3]4][5[6[7]8]9]0]"
+	first := MinimizeGrammar(input, new(minimizeList), interesting)
+	for i := 0; i < 20; i++ {
+		if again := MinimizeGrammar(input, new(minimizeList), interesting); again != first {
+			t.Fatalf("expected the same result every time, but got %q and %q", first, again)
+		}
+	}
+}
@@ -13,7 +13,7 @@ func parseNegative(state *State, into reflect.Type, tag reflect.StructTag) (inte
 	// Negative lookahead.
 	_, err := parseIntoType(state, into.Elem(), tag)
 	if err == nil {
-		return nil, fmt.Errorf("expected %s to fail", tag.Get(`name`))
+		return nil, state.Errorf("expected %s to fail", tag.Get(`name`))
 	}
 	return reflect.Zero(into).Interface(), nil
 }
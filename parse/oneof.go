@@ -0,0 +1,170 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// oneOfRegistry holds the maps given to RegisterConstants and RegisterOneOf.
+var oneOfRegistry = struct {
+	sync.Mutex
+	constants map[reflect.Type]reflect.Value
+	spellings map[string]reflect.Value
+	compiled  map[reflect.Type]map[reflect.StructTag][]oneOfSpelling
+}{
+	constants: map[reflect.Type]reflect.Value{},
+	spellings: map[string]reflect.Value{},
+	compiled:  map[reflect.Type]map[reflect.StructTag][]oneOfSpelling{},
+}
+
+// RegisterConstants gives the names of the constants of a named integer or string type,
+// as a map such as map[string]Op{"Add": Add, "Sub": Sub}, so that fields of that type can
+// be tagged with oneof:"+=Add,-=Sub". Without it, fields of string types store the name itself.
+// In a spelling, ',' and '\' are escaped with '\'. A oneof field has no span; a field of
+// type Span tagged span:"<field>" records it.
+func RegisterConstants(constants interface{}) {
+	value := oneOfMap(constants)
+	oneOfRegistry.Lock()
+	defer oneOfRegistry.Unlock()
+	oneOfRegistry.constants[value.Type().Elem()] = value
+}
+
+// RegisterOneOf names a map from spellings to values, such as map[string]Op{"+": Add},
+// so that fields of its value type can be tagged with oneof:"<name>" to match any of them.
+func RegisterOneOf(name string, spellings interface{}) {
+	value := oneOfMap(spellings)
+	oneOfRegistry.Lock()
+	defer oneOfRegistry.Unlock()
+	oneOfRegistry.spellings[name] = value
+}
+
+func oneOfMap(values interface{}) reflect.Value {
+	value := reflect.ValueOf(values)
+	if value.Kind() != reflect.Map || value.Type().Key().Kind() != reflect.String || !isOneOfKind(value.Type().Elem()) {
+		panic(fmt.Sprintf("expected a map from strings to an integer or string type, but got %T", values))
+	}
+	return value
+}
+
+func isOneOfKind(into reflect.Type) bool {
+	switch into.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.String:
+		return true
+	}
+	return false
+}
+
+type oneOfSpelling struct {
+	spelling []byte
+	value    reflect.Value
+}
+
+// parseOneOf parses a field of an integer or string type tagged with oneof, matching the
+// longest of its spellings and producing the value for it.
+func parseOneOf(state *State, into reflect.Type, tag reflect.StructTag) (interface{}, error) {
+	spellings := oneOfSpellings(into, tag)
+	rest := state.Rest()
+	for _, each := range spellings {
+		if bytes.HasPrefix(rest, each.spelling) {
+			state.Advance(len(each.spelling))
+			return each.value.Interface(), nil
+		}
+	}
+	quoted := make([]string, len(spellings))
+	for i, each := range spellings {
+		quoted[i] = strconv.Quote(string(each.spelling))
+	}
+	sort.Strings(quoted)
+	return nil, state.Errorf("Expected one of %s", strings.Join(quoted, ", "))
+}
+
+// oneOfSpellings reads the spellings of a oneof tag, longest first.
+func oneOfSpellings(into reflect.Type, tag reflect.StructTag) []oneOfSpelling {
+	oneOfRegistry.Lock()
+	defer oneOfRegistry.Unlock()
+	if spellings, ok := oneOfRegistry.compiled[into][tag]; ok {
+		return spellings
+	}
+	source := tag.Get("oneof")
+	spellings := []oneOfSpelling{}
+	if !strings.Contains(source, "=") {
+		registered, ok := oneOfRegistry.spellings[source]
+		if !ok {
+			panic(fmt.Sprintf("oneof field of type %+v refers to %q, which was not given to RegisterOneOf", into, source))
+		}
+		if registered.Type().Elem() != into {
+			panic(fmt.Sprintf("oneof field of type %+v refers to %q, which holds %+v", into, source, registered.Type().Elem()))
+		}
+		for _, key := range registered.MapKeys() {
+			spellings = append(spellings, oneOfSpelling{[]byte(key.String()), registered.MapIndex(key)})
+		}
+	} else {
+		for _, pair := range splitOneOf(source) {
+			equals := strings.LastIndex(pair, "=")
+			if equals <= 0 {
+				panic(fmt.Sprintf("oneof field of type %+v given illegal spelling %q: %q", into, pair, tag))
+			}
+			spellings = append(spellings, oneOfSpelling{[]byte(unescapeOneOf(pair[:equals])), oneOfConstant(into, pair[equals+1:], tag)})
+		}
+	}
+	sort.SliceStable(spellings, func(i, j int) bool {
+		if len(spellings[i].spelling) != len(spellings[j].spelling) {
+			return len(spellings[i].spelling) > len(spellings[j].spelling)
+		}
+		return bytes.Compare(spellings[i].spelling, spellings[j].spelling) < 0
+	})
+	if oneOfRegistry.compiled[into] == nil {
+		oneOfRegistry.compiled[into] = map[reflect.StructTag][]oneOfSpelling{}
+	}
+	oneOfRegistry.compiled[into][tag] = spellings
+	return spellings
+}
+
+// splitOneOf splits a oneof tag at each ',' that isn't escaped with '\'.
+func splitOneOf(source string) []string {
+	pairs := []string{}
+	start := 0
+	for i := 0; i < len(source); i++ {
+		switch source[i] {
+		case '\\':
+			i++
+		case ',':
+			pairs = append(pairs, source[start:i])
+			start = i + 1
+		}
+	}
+	return append(pairs, source[start:])
+}
+
+// unescapeOneOf removes the '\' before each escaped character of a spelling.
+func unescapeOneOf(spelling string) string {
+	var out strings.Builder
+	for i := 0; i < len(spelling); i++ {
+		if spelling[i] == '\\' && i+1 < len(spelling) {
+			i++
+		}
+		out.WriteByte(spelling[i])
+	}
+	return out.String()
+}
+
+// oneOfConstant finds the value of a constant by name. Registry lock must be held.
+func oneOfConstant(into reflect.Type, name string, tag reflect.StructTag) reflect.Value {
+	if constants, ok := oneOfRegistry.constants[into]; ok {
+		value := constants.MapIndex(reflect.ValueOf(name))
+		if !value.IsValid() {
+			panic(fmt.Sprintf("oneof field of type %+v names unknown constant %q: %q", into, name, tag))
+		}
+		return value
+	}
+	if into.Kind() != reflect.String {
+		panic(fmt.Sprintf("oneof field of type %+v names constant %q, but its constants were not given to RegisterConstants", into, name))
+	}
+	return reflect.ValueOf(name).Convert(into)
+}
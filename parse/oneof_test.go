@@ -0,0 +1,73 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+type oneOfOperator int
+
+const (
+	oneOfAdd oneOfOperator = iota
+	oneOfAddAssign
+	oneOfSub
+	oneOfShift
+	oneOfLess
+)
+
+type oneOfComparison string
+
+func init() {
+	RegisterConstants(map[string]oneOfOperator{"Add": oneOfAdd, "AddAssign": oneOfAddAssign, "Sub": oneOfSub})
+	RegisterOneOf("comparisons", map[string]oneOfOperator{"<": oneOfLess, "<<": oneOfShift})
+}
+
+type oneOfExpression struct {
+	Left       Int
+	Operator   oneOfOperator `oneof:"+=Add,-=Sub,+==AddAssign"`
+	Right      Int
+	Comparison oneOfOperator   `oneof:"comparisons"`
+	Word       oneOfComparison `oneof:"lt=Less,le=LessEqual"`
+}
+
+func TestOneOf(t *testing.T) {
+	expression := oneOfExpression{}
+	if err := Parse("1+=2<<le", &expression); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expression.Operator != oneOfAddAssign || expression.Comparison != oneOfShift || expression.Word != "LessEqual" {
+		t.Errorf("expected the longest spellings, got %+v", expression)
+	}
+	if err := Parse("1-2<lt", &expression); err != nil || expression.Operator != oneOfSub || expression.Comparison != oneOfLess {
+		t.Errorf("expected Sub and Less, got %+v (%v)", expression, err)
+	}
+	err := Parse("1*2", &expression)
+	if err == nil || !strings.HasPrefix(err.Error(), `Expected one of "+", "+=", "-" at 1:2`) {
+		t.Errorf("expected the spellings in the error, got %v", err)
+	}
+}
+
+type oneOfUnary struct {
+	Operator     oneOfComparison `oneof:"-=Negate,\\,=Comma,\\\\=Backslash"`
+	OperatorSpan Span            `span:"Operator"`
+	Operand      Int
+}
+
+func TestOneOfSpan(t *testing.T) {
+	unary := oneOfUnary{}
+	if err := Parse("-12", &unary); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if text := string(unary.OperatorSpan.Text()); text != "-" {
+		t.Errorf("expected the span of the operator, got %q", text)
+	}
+	if text := string(Text(unary)); text != "-12" {
+		t.Errorf("expected the text to keep the operator, got %q", text)
+	}
+	if err := Parse(",1", &unary); err != nil || unary.Operator != "Comma" {
+		t.Errorf("expected an escaped comma, got %+v (%v)", unary, err)
+	}
+	if err := Parse("\\1", &unary); err != nil || unary.Operator != "Backslash" {
+		t.Errorf("expected an escaped backslash, got %+v (%v)", unary, err)
+	}
+}
@@ -0,0 +1,129 @@
+package parse
+
+import (
+	"fmt"
+	"go/token"
+	"reflect"
+)
+
+// A Position is a place in the source.
+// Filename and Pos are only set when the source was registered with a FileSet,
+// TokenPos when it was added to a go/token FileSet, and EncodedOffset when it was
+// decoded from another encoding.
+// Offset counts bytes from the start of the (decoded) source, starting at 0.
+// Line and Column start at 1. Column counts in the ColumnUnit and tab width chosen for the
+// parse, while ByteColumn counts bytes from the start of the line, as go/token does.
+type Position struct {
+	Filename      string
+	Pos           Pos
+	TokenPos      token.Pos
+	Offset        int
+	EncodedOffset int
+	Line          int
+	Column        int
+	ByteColumn    int
+}
+
+// String formats the position as "line:column", or "filename:line:column".
+func (p Position) String() string {
+	if p.Filename != "" {
+		return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// A Span is the range of source from Start up to (but not including) End.
+// File refers to the source it was parsed from.
+type Span struct {
+	File  *File
+	Start Position
+	End   Position
+}
+
+// String formats the span as "line:column-line:column", with the filename in front if known.
+func (s Span) String() string {
+	return fmt.Sprintf("%s-%d:%d", s.Start, s.End.Line, s.End.Column)
+}
+
+// Text is the exact source in the span, or nil if the span has no file.
+func (s Span) Text() []byte {
+	if s.File == nil {
+		return nil
+	}
+	return s.File.Source[s.Start.Offset:s.End.Offset]
+}
+
+// Len is the number of bytes in the span.
+func (s Span) Len() int {
+	return s.End.Offset - s.Start.Offset
+}
+
+// A ColumnUnit is what the columns of positions count.
+type ColumnUnit int
+
+const (
+	// ColumnBytes counts each byte as a column.
+	ColumnBytes ColumnUnit = iota
+	// ColumnRunes counts each UTF-8 encoded character as a column.
+	ColumnRunes
+	// ColumnUTF16 counts UTF-16 code units, as the Language Server Protocol does.
+	ColumnUTF16
+)
+
+// WithColumns chooses what the columns of positions count. By default, they count bytes.
+func WithColumns(unit ColumnUnit) Option {
+	return func(state *State) {
+		state.Columns = unit
+	}
+}
+
+// WithTabWidth sets the distance between tab stops for the columns of positions.
+// By default, it is 4.
+func WithTabWidth(width int) Option {
+	return func(state *State) {
+		state.TabWidth = width
+	}
+}
+
+var spanType = reflect.TypeOf(Span{})
+
+// isSpanField reports whether a field records the span of its struct, rather than
+// being part of the grammar. That's the case for an embedded Span, or a Span
+// tagged with parse:"span".
+func isSpanField(field reflect.StructField) bool {
+	return field.Type == spanType && (field.Anonymous || field.Tag.Get("parse") == "span")
+}
+
+// spannedField finds the name of the field whose span a field records, for fields of type
+// Span tagged span:"<field>". Such fields are not part of the grammar.
+func spannedField(field reflect.StructField) (string, bool) {
+	name := field.Tag.Get("span")
+	return name, field.Type == spanType && name != ""
+}
+
+// fillFieldSpans sets the span fields of a struct value that record the field just parsed,
+// from start up to the current position.
+func fillFieldSpans(state *State, value reflect.Value, field int, start int) {
+	name := value.Type().Field(field).Name
+	for i := 0; i < value.NumField(); i++ {
+		if spanned, ok := spannedField(value.Type().Field(i)); ok && spanned == name {
+			value.Field(i).Set(reflect.ValueOf(state.SpanFrom(start)))
+		}
+	}
+}
+
+// fillSpans sets every span field of a struct value that was parsed from start up to the
+// current position.
+func fillSpans(state *State, value reflect.Value, start int) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if isSpanField(field) {
+			value.Field(i).Set(reflect.ValueOf(state.SpanFrom(start)))
+		}
+		if spanned, ok := spannedField(field); ok {
+			if _, found := value.Type().FieldByName(spanned); !found {
+				panic(fmt.Sprintf("span field %s of %+v refers to missing field %q", field.Name, value.Type(), spanned))
+			}
+		}
+	}
+}
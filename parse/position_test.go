@@ -0,0 +1,80 @@
+package parse
+
+import "testing"
+
+type positionExample struct {
+	Words []positionWord
+	End   Location
+}
+
+type positionWord struct {
+	Space Regex `regex:"\\s*"`
+	Word  Regex `regex:"[a-z]+"`
+}
+
+func TestPositions(t *testing.T) {
+	var result positionExample
+	if err := Parse("ab\n\tcd\r\nef", &result); err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	if span := result.Words[1].Word.Span; span.Start != (Position{Offset: 4, Line: 2, Column: 5, ByteColumn: 2}) || span.End != (Position{Offset: 6, Line: 2, Column: 7, ByteColumn: 4}) {
+		t.Errorf("unexpected span %+v", span)
+	}
+	if start := result.Words[2].Word.Span.Start; start.String() != "3:1" {
+		t.Errorf("unexpected position %s", start)
+	}
+	if end := result.End.Position; end.Offset != 10 {
+		t.Errorf("unexpected end position %+v", end)
+	}
+}
+
+type spanCall struct {
+	Span
+	Name      Regex `regex:"[a-z]+"`
+	Open      Open
+	Arguments []spanArgument
+	Close     Close
+}
+
+type spanArgument struct {
+	Choice `name:"argument"`
+	Number Number
+	Call   spanCall
+	Where  Span `parse:"span"`
+}
+
+func TestSpanCapture(t *testing.T) {
+	var result spanCall
+	if err := Parse("f(1g(2))", &result); err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	if result.Start.Offset != 0 || result.End.Offset != 8 {
+		t.Errorf("unexpected span %s", result.Span)
+	}
+	inner := result.Arguments[1]
+	if inner.Where.Start.Offset != 3 || inner.Where.End.Offset != 7 {
+		t.Errorf("unexpected argument span %s", inner.Where)
+	}
+	if inner.Call.Span != inner.Where {
+		t.Errorf("expected call span %s to match argument span %s", inner.Call.Span, inner.Where)
+	}
+}
+
+type columnsExample struct {
+	Text Regex `regex:".*"`
+	End  Location
+}
+
+func TestColumns(t *testing.T) {
+	source := "\tä😀x"
+	expected := map[ColumnUnit]int{ColumnBytes: 16, ColumnRunes: 12, ColumnUTF16: 13}
+	for unit, column := range expected {
+		var result columnsExample
+		if err := Parse(source, &result, WithColumns(unit), WithTabWidth(8)); err != nil {
+			t.Fatalf("error ``%s'' unexpected", err)
+		}
+		if end := result.End.Position; end.Column != column || end.Offset != len(source) {
+			t.Errorf("expected column %d counting %d but got %+v", column, unit, end)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MarkdownReference loads the Go package in dir and produces a Markdown language reference
+// for the grammar it defines. Each struct type becomes a section combining its doc comment
+// with its rule, and the literal keywords of the language are listed at the end.
+// If root is not empty, only the rules reachable from the type with that name are included,
+// starting with it; otherwise every struct type in the package is included.
+func MarkdownReference(dir string, root string) (string, error) {
+	fileSet := token.NewFileSet()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	files := []*ast.File{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fileSet, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return "", err
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no Go files in %s", dir)
+	}
+	pkg, err := doc.NewFromFiles(fileSet, files, dir, doc.AllDecls|doc.PreserveAST)
+	if err != nil {
+		return "", err
+	}
+	reference := &reference{
+		parseNames: map[string]bool{},
+		rules:      map[string]*ast.StructType{},
+		docs:       map[string]string{},
+		keywords:   map[string][]string{},
+		usedBy:     map[string][]string{},
+	}
+	for _, file := range files {
+		for _, spec := range file.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			if path != "parse" && !strings.HasSuffix(path, "/parse") {
+				continue
+			}
+			if spec.Name != nil {
+				reference.parseNames[spec.Name.Name] = true
+			} else {
+				reference.parseNames["parse"] = true
+			}
+		}
+	}
+	names := []string{}
+	for _, kind := range pkg.Types {
+		for _, spec := range kind.Decl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok || typeSpec.Name.Name != kind.Name {
+				continue
+			}
+			if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+				reference.rules[kind.Name] = structType
+				reference.docs[kind.Name] = kind.Doc
+				names = append(names, kind.Name)
+			}
+		}
+	}
+	if root != "" {
+		if _, ok := reference.rules[root]; !ok {
+			return "", fmt.Errorf("no struct type %s in %s", root, dir)
+		}
+		names = reference.reachable(root)
+	}
+	for _, name := range names {
+		reference.collect(name)
+	}
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "# %s language reference\n\n", pkg.Name)
+	if pkg.Doc != "" {
+		fmt.Fprintf(&out, "%s\n", strings.TrimSpace(pkg.Doc))
+	}
+	for _, name := range names {
+		reference.section(&out, name)
+	}
+	reference.keywordSection(&out)
+	return out.String(), nil
+}
+
+type reference struct {
+	// parseNames are the names the parse package is imported under.
+	parseNames map[string]bool
+	rules      map[string]*ast.StructType
+	docs       map[string]string
+	// keywords maps each literal to the rules that use it.
+	keywords map[string][]string
+	// usedBy maps each rule to the rules that refer to it.
+	usedBy map[string][]string
+}
+
+// reachable lists the rules reachable from root, in breadth-first order.
+func (r *reference) reachable(root string) []string {
+	names := []string{root}
+	seen := map[string]bool{root: true}
+	for i := 0; i < len(names); i++ {
+		for _, field := range r.rules[names[i]].Fields.List {
+			for _, name := range r.references(field.Type) {
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// references finds the local rules named inside of a field type.
+func (r *reference) references(expr ast.Expr) []string {
+	found := []string{}
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok {
+			if _, ok := r.rules[ident.Name]; ok {
+				found = append(found, ident.Name)
+			}
+		}
+		_, selector := node.(*ast.SelectorExpr)
+		return !selector
+	})
+	return found
+}
+
+// collect records the keywords and references of a rule.
+func (r *reference) collect(name string) {
+	for _, field := range r.rules[name].Fields.List {
+		if literal, ok := r.literal(field.Type, fieldTag(field)); ok {
+			if users := r.keywords[literal]; len(users) == 0 || users[len(users)-1] != name {
+				r.keywords[literal] = append(users, name)
+			}
+		}
+		for _, other := range r.references(field.Type) {
+			if users := r.usedBy[other]; len(users) == 0 || users[len(users)-1] != name {
+				r.usedBy[other] = append(users, name)
+			}
+		}
+	}
+}
+
+func fieldTag(field *ast.Field) reflect.StructTag {
+	if field.Tag == nil {
+		return ""
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	return reflect.StructTag(tag)
+}
+
+// leaf returns the name of the parse package type that expr refers to, if any.
+func (r *reference) leaf(expr ast.Expr) (string, bool) {
+	selector, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+	pkg, ok := selector.X.(*ast.Ident)
+	if !ok || !r.parseNames[pkg.Name] {
+		return "", false
+	}
+	return selector.Sel.Name, true
+}
+
+// literal returns the exact text matched by a field, if it is a literal.
+func (r *reference) literal(expr ast.Expr, tag reflect.StructTag) (string, bool) {
+	switch name, _ := r.leaf(expr); name {
+	case "Literal", "Keyword":
+		return tag.Get("parse"), tag.Get("parse") != ""
+	case "Open":
+		return "(", true
+	case "Close":
+		return ")", true
+	}
+	return "", false
+}
+
+func (r *reference) isChoice(structType *ast.StructType) bool {
+	if len(structType.Fields.List) == 0 {
+		return false
+	}
+	name, ok := r.leaf(structType.Fields.List[0].Type)
+	return ok && name == "Choice"
+}
+
+// isSpan reports whether a field records the span of its struct instead of being parsed.
+func (r *reference) isSpan(field *ast.Field) bool {
+	name, ok := r.leaf(field.Type)
+	return ok && name == "Span" && (len(field.Names) == 0 || fieldTag(field).Get("parse") == "span")
+}
+
+// describe renders what a field of the given type matches, linking to other rules.
+func (r *reference) describe(expr ast.Expr, tag reflect.StructTag) string {
+	if literal, ok := r.literal(expr, tag); ok {
+		if tag.Get("case") == "fold" {
+			return markdownCode(strconv.Quote(literal)) + " in any case"
+		}
+		return markdownCode(strconv.Quote(literal))
+	}
+	if oneOf := tag.Get("oneof"); oneOf != "" {
+		if !strings.Contains(oneOf, "=") {
+			return "one of the spellings registered as " + markdownCode(oneOf)
+		}
+		spellings := []string{}
+		for _, pair := range splitOneOf(oneOf) {
+			if equals := strings.LastIndex(pair, "="); equals > 0 {
+				spellings = append(spellings, markdownCode(strconv.Quote(unescapeOneOf(pair[:equals]))))
+			}
+		}
+		return "one of " + strings.Join(spellings, ", ")
+	}
+	if name, ok := r.leaf(expr); ok {
+		switch name {
+		case "Regex":
+			return "text matching " + markdownCode(tag.Get("regex"))
+		case "Number":
+			return "a number"
+		case "Int", "Uint":
+			return "an integer"
+		case "String":
+			return "a quoted string"
+		case "LineComment":
+			return "a comment starting with " + markdownCode(commentDefault(tag.Get("comment"), "//"))
+		case "BlockComment":
+			return "a comment between " + markdownCode(commentDefault(tag.Get("open"), "/*")) + " and " + markdownCode(commentDefault(tag.Get("close"), "*/"))
+		case "Duration":
+			return "a duration"
+		case "Time":
+			return "a time in layout " + markdownCode(timeLayout(tag))
+		case "Addr":
+			return "an IP address"
+		case "Prefix":
+			return "an IP prefix"
+		case "AddrPort":
+			return "an IP address and port"
+		case "Identifier", "UnicodeIdentifier":
+			return "an identifier"
+		case "Class":
+			return "characters in " + markdownCode(tag.Get("class"))
+		case "Location":
+			return "nothing (records the location)"
+		}
+		return markdownCode(types.ExprString(expr))
+	}
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if _, ok := r.rules[expr.Name]; ok {
+			return fmt.Sprintf("[%s](#%s)", expr.Name, strings.ToLower(expr.Name))
+		}
+	case *ast.ParenExpr:
+		return r.describe(expr.X, tag)
+	case *ast.StarExpr:
+		return "optional " + r.describe(expr.X, tag)
+	case *ast.ArrayType:
+		if expr.Len == nil {
+			return "zero or more " + r.describe(expr.Elt, tag)
+		}
+	case *ast.ChanType:
+		switch expr.Dir {
+		case ast.RECV:
+			return "followed by " + r.describe(expr.Value, tag)
+		case ast.SEND:
+			return "not followed by " + r.describe(expr.Value, tag)
+		}
+	}
+	return markdownCode(types.ExprString(expr))
+}
+
+func commentDefault(delimiter string, fallback string) string {
+	if delimiter == "" {
+		return fallback
+	}
+	return delimiter
+}
+
+// section writes the documentation for a single rule.
+func (r *reference) section(out *bytes.Buffer, name string) {
+	structType := r.rules[name]
+	fmt.Fprintf(out, "\n## %s\n\n", name)
+	if doc := strings.TrimSpace(r.docs[name]); doc != "" {
+		fmt.Fprintf(out, "%s\n\n", doc)
+	}
+	fields := structType.Fields.List
+	if r.isChoice(structType) {
+		if alias := fieldTag(fields[0]).Get("name"); alias != "" {
+			fmt.Fprintf(out, "Named *%s* in error messages. ", alias)
+		}
+		fmt.Fprintf(out, "Matches the first of:\n\n")
+		fields = fields[1:]
+	} else {
+		fmt.Fprintf(out, "Matches in sequence:\n\n")
+	}
+	fmt.Fprintf(out, "| Field | Matches | Description |\n")
+	fmt.Fprintf(out, "| --- | --- | --- |\n")
+	for _, field := range fields {
+		if name, _ := r.leaf(field.Type); r.isSpan(field) || name == "Lexical" {
+			continue
+		}
+		description := ""
+		if field.Doc != nil {
+			description = field.Doc.Text()
+		} else if field.Comment != nil {
+			description = field.Comment.Text()
+		}
+		description = strings.Join(strings.Fields(description), " ")
+		matches := r.describe(field.Type, fieldTag(field))
+		fieldNames := []string{}
+		for _, fieldName := range field.Names {
+			fieldNames = append(fieldNames, fieldName.Name)
+		}
+		if len(fieldNames) == 0 {
+			fieldNames = append(fieldNames, "(embedded)")
+		}
+		for _, fieldName := range fieldNames {
+			fmt.Fprintf(out, "| %s | %s | %s |\n", fieldName, markdownCell(matches), markdownCell(description))
+		}
+	}
+	if users := r.usedBy[name]; len(users) > 0 {
+		links := make([]string, len(users))
+		for i, user := range users {
+			links[i] = fmt.Sprintf("[%s](#%s)", user, strings.ToLower(user))
+		}
+		fmt.Fprintf(out, "\nUsed by %s.\n", strings.Join(links, ", "))
+	}
+}
+
+// keywordSection lists every literal in the grammar along with the rules using it.
+func (r *reference) keywordSection(out *bytes.Buffer) {
+	if len(r.keywords) == 0 {
+		return
+	}
+	literals := []string{}
+	for literal := range r.keywords {
+		literals = append(literals, literal)
+	}
+	sort.Strings(literals)
+	fmt.Fprintf(out, "\n## Keywords\n\n")
+	fmt.Fprintf(out, "| Literal | Used by |\n")
+	fmt.Fprintf(out, "| --- | --- |\n")
+	for _, literal := range literals {
+		links := []string{}
+		for _, user := range r.keywords[literal] {
+			links = append(links, fmt.Sprintf("[%s](#%s)", user, strings.ToLower(user)))
+		}
+		fmt.Fprintf(out, "| %s | %s |\n", markdownCell(markdownCode(literal)), strings.Join(links, ", "))
+	}
+}
+
+// markdownCode wraps text in a code span, using enough backticks to contain it.
+func markdownCode(text string) string {
+	fence := "`"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") || text == "" {
+		return fence + " " + text + " " + fence
+	}
+	return fence + text + fence
+}
+
+// markdownCell escapes text for use inside a table cell.
+func markdownCell(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
+}
@@ -0,0 +1,81 @@
+package parse
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const referenceSource = `// Package lisp is a tiny language.
+package lisp
+
+import "github.com/Nathan-Fenner/Reflect-Peg/parse"
+
+// An Expression is a call or a variable.
+type Expression struct {
+	parse.Choice ` + "`name:\"expression\"`" + `
+	Call     Call
+	Variable parse.Regex ` + "`regex:\"[a-z]+\"`" + `
+}
+
+// A Call applies a function.
+type Call struct {
+	Open      parse.Open
+	Arguments []Expression // The arguments, in order.
+	Close     parse.Close
+}
+
+type unused struct {
+	If parse.Literal ` + "`parse:\"if\"`" + `
+}
+`
+
+func TestMarkdownReference(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "lisp.go"), []byte(referenceSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	markdown, err := MarkdownReference(dir, "Expression")
+	if err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	expected := []string{
+		"# lisp language reference",
+		"## Expression\n\nAn Expression is a call or a variable.",
+		"| Call | [Call](#call) |",
+		"| Arguments | zero or more [Expression](#expression) | The arguments, in order. |",
+		"Used by [Expression](#expression).",
+		"| `(` | [Call](#call) |",
+	}
+	for _, each := range expected {
+		if !strings.Contains(markdown, each) {
+			t.Errorf("expected %q in:\n%s", each, markdown)
+		}
+	}
+	if strings.Contains(markdown, "unused") {
+		t.Errorf("expected unreachable rule to be left out of:\n%s", markdown)
+	}
+}
+
+const referenceOneOfSource = `package list
+
+// A Separator comes between items.
+type Separator struct {
+	Mark string ` + "`oneof:\"\\\\,=Comma,\\\\\\\\=Backslash,;=Semicolon\"`" + `
+}
+`
+
+func TestMarkdownReferenceOneOf(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "list.go"), []byte(referenceOneOfSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	markdown, err := MarkdownReference(dir, "Separator")
+	if err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	if want := "one of `\",\"`, `\"\\\\\"`, `\";\"`"; !strings.Contains(markdown, want) {
+		t.Errorf("expected %q in:\n%s", want, markdown)
+	}
+}
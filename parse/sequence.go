@@ -5,6 +5,13 @@ import "reflect"
 // parseSequence is given a struct type representing a sequence.
 func parseSequence(state *State, into reflect.Type, tag reflect.StructTag) (interface{}, error) {
 	currentField := 0
+	start := state.Position
+	lexical := isLexical(into)
+	if lexical {
+		// A lexical rule is a token, so the trivia before it is skipped as for a leaf.
+		skipTrivia(state)
+		start = state.Position
+	}
 	value := reflect.New(into).Elem()
 	defer func() {
 		recovered := recover()
@@ -32,13 +39,41 @@ func parseSequence(state *State, into reflect.Type, tag reflect.StructTag) (inte
 		}
 		panic(fatal)
 	}()
+	// Trivia before a first field parsed without skipping trivia is part of it.
+	untrimmed := lexical
 	for currentField < into.NumField() {
-		result, err := parseIntoType(state, into.Field(currentField).Type, into.Field(currentField).Tag)
+		if !isGrammarField(into, currentField) {
+			currentField++
+			continue
+		}
+		if start == state.Position && into.Field(currentField).Tag.Get("trivia") == "-" {
+			untrimmed = true
+		}
+		var result interface{}
+		var err error
+		before := state.Position
+		if into.Field(currentField).Tag.Get("include") != "" {
+			result = parseInclude(state, value, into.Field(currentField))
+		} else {
+			field := into.Field(currentField)
+			withoutTrivia(state, lexical || field.Tag.Get("trivia") == "-", func() {
+				result, err = parseIntoType(state, field.Type, field.Tag)
+			})
+		}
+		state.recordCoverage(into, currentField, result, err)
 		if err != nil {
 			return nil, err
 		}
 		value.Field(currentField).Set(reflect.ValueOf(result))
+		if end := afterTrivia(state, before); end <= state.Position && into.Field(currentField).Tag.Get("trivia") != "-" {
+			before = end
+		}
+		fillFieldSpans(state, value, currentField, before)
 		currentField++
 	}
+	if end := afterTrivia(state, start); end <= state.Position && !untrimmed {
+		start = end
+	}
+	fillSpans(state, value, start)
 	return value.Interface(), nil
 }
@@ -1,19 +1,28 @@
 package parse
 
 import (
-	"fmt"
+	"go/token"
+	"io/fs"
 	"reflect"
+	"sort"
 )
 
 type Output struct {
 	Result   interface{}
 	Error    error
 	Position int
+	// coverage holds the coverage events recorded while the result was parsed.
+	coverage []coverageEvent
+	// order counts the results memoized before this one.
+	order int
 }
 
 type Input struct {
 	Position int
 	Type     reflect.Type
+	Tag      reflect.StructTag
+	// Lexical is set when trivia isn't being skipped.
+	Lexical bool
 }
 
 type State struct {
@@ -21,29 +30,88 @@ type State struct {
 	Position int
 	Memory   map[Input]Output
 	Learning map[Input]bool
+	Coverage *Coverage
+	Columns  ColumnUnit
+	// TabWidth is the distance between tab stops, 4 if not set.
+	TabWidth     int
+	FileSet      *FileSet
+	TokenFileSet *token.FileSet
+	Filename     string
+	// LineEndings and SkipBOM are set by CountLineEndings, NormalizeLineEndings and SkipBOM.
+	LineEndings bool
+	SkipBOM     bool
+	normalize   bool
+	Encoding    Encoding
+	FS          fs.FS
+	// IsNFC checks that each UnicodeIdentifier is normalized, when set by RequireNFC.
+	IsNFC func(string) bool
+	// Trivia is skipped before each token, when set by WithTrivia.
+	Trivia  reflect.Type
+	lexical bool
+	// Reserved holds the words that an Identifier won't match.
+	Reserved map[string]bool
+	// FoldedReserved holds the words that an Identifier won't match in any case, as
+	// spelled by foldCase.
+	FoldedReserved map[string]bool
+	// includes lists the files that included this one, outermost first.
+	includes []string
+	// File is created from the fields above once it's needed.
+	File *File
+	// shifts holds, for each byte removed from the original source, the first offset into
+	// Source that comes after it.
+	shifts []int
+	// coverageLog holds the coverage events of the parse so far, when there is a Coverage.
+	coverageLog []coverageEvent
 }
 
 func (s *State) Rest() []byte {
 	return s.Source[s.Position:]
 }
 
-func (s *State) Location() string {
-	line := 0
-	column := 0
-	for i := 0; i < s.Position; i++ {
-		switch s.Source[i] {
-		case '\r':
-			column = 0
-		case '\n':
-			line++
-			column = 0
-		case '\t':
-			column /= 4
-			column++
-			column *= 4
-		default:
-			column++
+// Location is the current position in the source.
+func (s *State) Location() Position {
+	return s.PositionAt(s.Position)
+}
+
+// SpanFrom is the span from the given byte offset up to the current position.
+func (s *State) SpanFrom(start int) Span {
+	return Span{File: s.file(), Start: s.PositionAt(start), End: s.Location()}
+}
+
+// Advance consumes the given number of bytes, and returns their span.
+func (s *State) Advance(n int) Span {
+	start := s.Position
+	s.Position += n
+	return s.SpanFrom(start)
+}
+
+// PositionAt finds the line and column of a byte offset into the source.
+// The position's offset is into the original source, before any normalization.
+func (s *State) PositionAt(offset int) Position {
+	return s.file().Position(s.originalOffset(offset))
+}
+
+// originalOffset maps an offset into Source back to the original source.
+func (s *State) originalOffset(offset int) int {
+	return offset + sort.Search(len(s.shifts), func(i int) bool { return s.shifts[i] > offset })
+}
+
+// file is the File for the source, registered with the FileSet if one was given.
+func (s *State) file() *File {
+	if s.File == nil {
+		if s.FileSet != nil {
+			s.File = s.FileSet.AddFile(s.Filename, s.Source)
+		} else {
+			s.File = newFile(s.Filename, 0, s.Source)
+		}
+		s.File.Columns = s.Columns
+		s.File.TabWidth = s.TabWidth
+		s.File.LineEndings = s.LineEndings
+		s.File.SkipBOM = s.SkipBOM
+		s.File.computeLines()
+		if s.TokenFileSet != nil {
+			s.File.Token = tokenFile(s.TokenFileSet, s.File)
 		}
 	}
-	return fmt.Sprintf("%d:%d", line+1, column+1)
+	return s.File
 }
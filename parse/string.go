@@ -0,0 +1,203 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// String is a quoted string literal, configured with tags:
+//
+//	quotes:"\"'"   the quote characters that may open (and must close) the string. Default `"`.
+//	escapes:"json" the escape dialect: go (the default), json, c, or sql.
+//
+// The go, json and c dialects escape with backslashes as in those languages, and don't allow
+// newlines inside the string. In the sql dialect there are no escapes besides doubling
+// the quote, and newlines are allowed. Strings quoted with '`' are raw, as in Go.
+// String holds the decoded value and Raw the source including quotes.
+// An invalid escape is a fatal error at its backslash.
+type String struct {
+	String string
+	Raw    string
+	Span   Span
+}
+
+func (s *String) ParseInto(state *State, tag reflect.StructTag) error {
+	quotes := tag.Get("quotes")
+	if quotes == "" {
+		quotes = `"`
+	}
+	dialect := tag.Get("escapes")
+	switch dialect {
+	case "":
+		dialect = "go"
+	case "go", "json", "c", "sql":
+	default:
+		panic(fmt.Sprintf("string given illegal 'escapes' tag: %q", tag))
+	}
+	rest := state.Rest()
+	quote, width := utf8.DecodeRune(rest)
+	if len(rest) == 0 || !strings.ContainsRune(quotes, quote) {
+		return state.Errorf("Expected string")
+	}
+	raw := quote == '`' || dialect == "sql"
+	var value strings.Builder
+	for i := width; ; {
+		if i >= len(rest) {
+			return state.Errorf("Unterminated string")
+		}
+		r, w := utf8.DecodeRune(rest[i:])
+		switch {
+		case r == quote && dialect == "sql" && bytes.HasPrefix(rest[i+w:], rest[i:i+w]):
+			value.WriteRune(quote)
+			i += 2 * w
+		case r == quote:
+			s.String = value.String()
+			s.Raw = string(rest[:i+w])
+			s.Span = state.Advance(i + w)
+			return nil
+		case r == '\n' && !raw:
+			return state.Errorf("Unterminated string")
+		case r == '\\' && !raw:
+			n, err := unescape(dialect, rest[i:], quote, &value)
+			if err != nil {
+				state.PanicAt(state.Position+i, "%s in string", err.Error())
+			}
+			i += n
+		case r < ' ' && dialect == "json":
+			state.PanicAt(state.Position+i, "control character %q in string", r)
+		default:
+			value.Write(rest[i : i+w])
+			i += w
+		}
+	}
+}
+
+// unescape decodes the escape at the start of text into out, returning the length of the escape.
+func unescape(dialect string, text []byte, quote rune, out *strings.Builder) (int, error) {
+	invalid := fmt.Errorf("invalid escape %q", escapeText(text))
+	switch dialect {
+	case "go":
+		// No Go escape is longer than the 10 bytes of \UXXXXXXXX.
+		window := text
+		if len(window) > 10 {
+			window = window[:10]
+		}
+		value, multibyte, tail, err := strconv.UnquoteChar(string(window), byte(quote))
+		if err != nil {
+			return 0, invalid
+		}
+		if value < utf8.RuneSelf || multibyte {
+			out.WriteRune(value)
+		} else {
+			// Octal and \x escapes are bytes rather than runes.
+			out.WriteByte(byte(value))
+		}
+		return len(window) - len(tail), nil
+	case "json":
+		if len(text) < 2 {
+			return 0, invalid
+		}
+		if simple, ok := jsonEscapes[text[1]]; ok {
+			out.WriteByte(simple)
+			return 2, nil
+		}
+		if text[1] != 'u' {
+			return 0, invalid
+		}
+		r, ok := hexValue(text[2:], 4)
+		if !ok {
+			return 0, invalid
+		}
+		if utf16.IsSurrogate(r) {
+			if low, ok := hexValue(bytes.TrimPrefix(text[6:], []byte(`\u`)), 4); ok && bytes.HasPrefix(text[6:], []byte(`\u`)) {
+				if pair := utf16.DecodeRune(r, low); pair != utf8.RuneError {
+					out.WriteRune(pair)
+					return 12, nil
+				}
+			}
+			// Like encoding/json, a lone surrogate becomes the replacement character.
+			r = utf8.RuneError
+		}
+		out.WriteRune(r)
+		return 6, nil
+	case "c":
+		if len(text) < 2 {
+			return 0, invalid
+		}
+		if simple, ok := cEscapes[text[1]]; ok {
+			out.WriteByte(simple)
+			return 2, nil
+		}
+		switch c := text[1]; {
+		case '0' <= c && c <= '7':
+			n, value := 1, rune(0)
+			for ; n <= 3 && n < len(text) && '0' <= text[n] && text[n] <= '7'; n++ {
+				value = value*8 + rune(text[n]-'0')
+			}
+			if value > 0xFF {
+				return 0, invalid
+			}
+			out.WriteByte(byte(value))
+			return n, nil
+		case c == 'x':
+			n, value := 2, rune(0)
+			for ; n < len(text) && digitValue(text[n]) < 16; n++ {
+				value = value*16 + rune(digitValue(text[n]))
+				if value > 0xFF {
+					return 0, invalid
+				}
+			}
+			if n == 2 {
+				return 0, invalid
+			}
+			out.WriteByte(byte(value))
+			return n, nil
+		case c == 'u' || c == 'U':
+			size := 4
+			if c == 'U' {
+				size = 8
+			}
+			r, ok := hexValue(text[2:], size)
+			if !ok || !utf8.ValidRune(r) {
+				return 0, invalid
+			}
+			out.WriteRune(r)
+			return 2 + size, nil
+		}
+	}
+	return 0, invalid
+}
+
+var jsonEscapes = map[byte]byte{'"': '"', '\\': '\\', '/': '/', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t'}
+
+var cEscapes = map[byte]byte{'a': '\a', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v', '\\': '\\', '\'': '\'', '"': '"', '?': '?'}
+
+// hexValue reads exactly size hexadecimal digits from the start of text.
+func hexValue(text []byte, size int) (rune, bool) {
+	if len(text) < size {
+		return 0, false
+	}
+	value := rune(0)
+	for i := 0; i < size; i++ {
+		digit := digitValue(text[i])
+		if digit >= 16 {
+			return 0, false
+		}
+		value = value*16 + rune(digit)
+	}
+	return value, true
+}
+
+// escapeText is the backslash and the character after it, for error messages.
+func escapeText(text []byte) string {
+	if len(text) < 2 {
+		return string(text)
+	}
+	_, width := utf8.DecodeRune(text[1:])
+	return string(text[:1+width])
+}
@@ -0,0 +1,83 @@
+package parse
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type stringHolder struct {
+	Go   String  "quotes:\"\\\"'`\""
+	Sep1 Literal `parse:" "`
+	JSON String  `escapes:"json"`
+	Sep2 Literal `parse:" "`
+	C    String  `escapes:"c"`
+	Sep3 Literal `parse:" "`
+	SQL  String  `quotes:"'" escapes:"sql"`
+}
+
+func TestString(t *testing.T) {
+	source := `"a\tb\x41é" "😀\/\n" "\101\x7\?" 'it''s'`
+	holder := stringHolder{}
+	if err := Parse(source, &holder); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got := []string{holder.Go.String, holder.JSON.String, holder.C.String, holder.SQL.String}
+	want := []string{"a\tbAé", "😀/\n", "A\x07?", "it's"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q but got %q", want, got)
+	}
+	if holder.SQL.Raw != `'it''s'` || string(holder.SQL.Span.Text()) != holder.SQL.Raw {
+		t.Errorf("unexpected raw source %q", holder.SQL.Raw)
+	}
+
+	if err := Parse("`a\\n` \"\" \"\" ''", &holder); err != nil || holder.Go.String != `a\n` {
+		t.Errorf("expected a raw string but got %q (%v)", holder.Go.String, err)
+	}
+
+	cases := []struct {
+		source string
+		err    string
+	}{
+		{`"ab\q" "" "" ''`, `invalid escape "\\q" in string at 1:4`},
+		{`"" "\u12" "" ''`, `invalid escape "\\u" in string at 1:5`},
+		{`"" "" "\x" ''`, `invalid escape "\\x" in string at 1:8`},
+		{`"" "" "\400" ''`, `invalid escape "\\4" in string at 1:8`},
+		{"\"\" \"a\tb\" \"\" ''", `control character '\t' in string at 1:6`},
+	}
+	for _, each := range cases {
+		err := Parse(each.source, &stringHolder{})
+		if err == nil || err.Error() != each.err {
+			t.Errorf("%s: expected %q but got %v", each.source, each.err, err)
+		}
+	}
+	if err := Parse(`"abc`, &String{}); err == nil || err.Error() != "Unterminated string at 1:1" {
+		t.Errorf("expected unterminated string but got %v", err)
+	}
+}
+
+func TestStringAllocations(t *testing.T) {
+	// A string copies only its own token, however much source follows it.
+	state := &State{Source: []byte(`"abc"` + strings.Repeat(" ", 100000))}
+	value := String{}
+	parse := func() {
+		state.Position = 0
+		if err := value.ParseInto(state, ""); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	allocs := testing.AllocsPerRun(100, parse)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	for i := 0; i < 100; i++ {
+		parse()
+	}
+	runtime.ReadMemStats(&after)
+	if perRun := (after.TotalAlloc - before.TotalAlloc) / 100; perRun > 1000 {
+		t.Errorf("expected a small allocation per string but got %d bytes in %v allocations", perRun, allocs)
+	}
+	if value.Raw != `"abc"` || value.String != "abc" {
+		t.Errorf("unexpected string %q from %q", value.String, value.Raw)
+	}
+}
@@ -0,0 +1,72 @@
+package parse
+
+import "reflect"
+
+// WithTrivia declares the trivia of a grammar, such as whitespace and comments, as the type
+// that target points to. Trivia is skipped before each leaf, and left out of the spans
+// of rules.
+// Nothing is skipped inside a rule marked with a Lexical field, nor inside a field
+// tagged trivia:"-", nor while parsing the trivia itself.
+func WithTrivia(target interface{}) Option {
+	pointer := reflect.TypeOf(target)
+	if pointer == nil || pointer.Kind() != reflect.Ptr {
+		panic("WithTrivia given non-pointer.")
+	}
+	return func(state *State) {
+		state.Trivia = pointer.Elem()
+	}
+}
+
+// Lexical marks a rule as a single token: trivia is skipped before it, but not inside it.
+// Like a span, a Lexical field is not part of the grammar.
+type Lexical struct{}
+
+var lexicalType = reflect.TypeOf(Lexical{})
+
+func isLexical(into reflect.Type) bool {
+	for i := 0; i < into.NumField(); i++ {
+		if into.Field(i).Type == lexicalType {
+			return true
+		}
+	}
+	return false
+}
+
+// skipTrivia skips any trivia at the current position.
+func skipTrivia(state *State) {
+	if state.Trivia == nil || state.lexical {
+		return
+	}
+	state.lexical = true
+	defer func() {
+		state.lexical = false
+	}()
+	// Trivia that doesn't match leaves the position where it was.
+	parseIntoType(state, state.Trivia, "")
+}
+
+// afterTrivia finds where the trivia at an offset ends, without moving.
+func afterTrivia(state *State, offset int) int {
+	if state.Trivia == nil || state.lexical {
+		return offset
+	}
+	position := state.Position
+	state.Position = offset
+	skipTrivia(state)
+	offset = state.Position
+	state.Position = position
+	return offset
+}
+
+// withoutTrivia parses with trivia skipping turned off, when lexical is set.
+func withoutTrivia(state *State, lexical bool, parse func()) {
+	if !lexical || state.lexical {
+		parse()
+		return
+	}
+	state.lexical = true
+	defer func() {
+		state.lexical = false
+	}()
+	parse()
+}
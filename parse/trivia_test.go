@@ -0,0 +1,98 @@
+package parse
+
+import "testing"
+
+type triviaSpace struct {
+	Items []triviaItem
+}
+
+type triviaItem struct {
+	Choice  `name:"trivia"`
+	Space   Class        `class:" \t\n"`
+	Comment LineComment  `comment:";"`
+	Block   BlockComment `open:"#|" close:"|#"`
+}
+
+type triviaList struct {
+	Open  Literal `parse:"("`
+	Items []triviaAtom
+	Close Literal `parse:")"`
+}
+
+type triviaAtom struct {
+	Choice `name:"atom"`
+	Name   Identifier
+	Quoted triviaQuoted
+	List   triviaList
+}
+
+// triviaQuoted keeps its spaces, since it is lexical.
+type triviaQuoted struct {
+	Lexical
+	Open  Literal `parse:"'"`
+	Text  Class   `class:"^'" min:"0"`
+	Close Literal `parse:"'"`
+}
+
+type triviaPair struct {
+	Key   Identifier
+	Colon Literal    `parse:":"`
+	Value Identifier `trivia:"-"`
+}
+
+// triviaToken is a choice of tokens, so no trivia is skipped inside of its alternatives.
+type triviaToken struct {
+	Choice `name:"token"`
+	Lexical
+	Arrow triviaArrow
+	Name  Identifier
+}
+
+type triviaArrow struct {
+	Dash  Literal `parse:"-"`
+	Angle Literal `parse:">"`
+}
+
+type triviaIndented struct {
+	Span
+	Indent Class `class:" " min:"0" trivia:"-"`
+	Name   Identifier
+}
+
+func TestTrivia(t *testing.T) {
+	list := triviaList{}
+	source := "( a ; comment\n  #| block |# ( b c ) ' x y ' )"
+	if err := Parse(source, &list, WithTrivia(&triviaSpace{})); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(list.Items) != 3 || string(list.Items[2].Quoted.Text.Contents) != " x y " {
+		t.Fatalf("unexpected result %+v", list)
+	}
+	if got := string(Text(list.Items[1])); got != "( b c )" {
+		t.Errorf("expected spans to leave out leading trivia, got %q", got)
+	}
+	if err := Parse(source, &list); err == nil {
+		t.Errorf("expected spaces to be rejected without trivia")
+	}
+
+	pair := triviaPair{}
+	if err := Parse("key : value", &pair, WithTrivia(&triviaSpace{})); err == nil {
+		t.Errorf("expected trivia not to be skipped before a trivia:\"-\" field")
+	}
+	if err := Parse(" key :value", &pair, WithTrivia(&triviaSpace{})); err != nil || string(pair.Value.Contents) != "value" {
+		t.Errorf("unexpected result %+v (%v)", pair, err)
+	}
+
+	token := triviaToken{}
+	if err := Parse(" ->", &token, WithTrivia(&triviaSpace{})); err != nil || token.Choice.Index != 2 {
+		t.Errorf("expected a lexical choice to skip the trivia before it, got %+v (%v)", token, err)
+	}
+	if err := Parse("- >", &token, WithTrivia(&triviaSpace{})); err == nil {
+		t.Errorf("expected a lexical choice not to skip trivia inside of it")
+	}
+
+	indented := triviaIndented{}
+	if err := Parse("  x", &indented, WithTrivia(&triviaSpace{})); err != nil || string(indented.Span.Text()) != "  x" {
+		t.Errorf("expected the span to start with a trivia:\"-\" field, got %q (%v)", indented.Span.Text(), err)
+	}
+}
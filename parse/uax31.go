@@ -0,0 +1,109 @@
+package parse
+
+import (
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+)
+
+// UnicodeIdentifier is an identifier in the default syntax of Unicode Standard Annex #31:
+// a character with the XID_Start property followed by characters with XID_Continue,
+// so that names in any script are accepted.
+// The tags extraStart:"<class>" and extraContinue:"<class>", in the syntax of Class, allow
+// more characters, such as extraStart:"$" or extraContinue:"-". Characters allowed at the
+// start may also continue an identifier.
+// Reserved words are rejected as for Identifier, and RequireNFC rejects identifiers that
+// are not in Normalization Form C.
+type UnicodeIdentifier struct {
+	Contents []byte
+	Span     Span
+}
+
+func (i *UnicodeIdentifier) ParseInto(state *State, tag reflect.StructTag) error {
+	extraStart := identifierClass(tag, "extraStart", "")
+	extraContinue := identifierClass(tag, "extraContinue", "")
+	rest := state.Rest()
+	r, size := utf8.DecodeRune(rest)
+	if len(rest) == 0 || !(isXIDStart(r) || extraStart.matches(r)) {
+		return state.Errorf("Expected identifier")
+	}
+	for size < len(rest) {
+		r, width := utf8.DecodeRune(rest[size:])
+		if !(isXIDContinue(r) || extraStart.matches(r) || extraContinue.matches(r)) {
+			break
+		}
+		size += width
+	}
+	name := string(rest[:size])
+	if state.isReserved(name) {
+		return state.Errorf("Expected identifier but found keyword %q", name)
+	}
+	if state.IsNFC != nil && !state.IsNFC(name) {
+		return state.Errorf("Expected identifier in Normalization Form C but found %q", name)
+	}
+	i.Contents = rest[:size]
+	i.Span = state.Advance(size)
+	return nil
+}
+
+// RequireNFC makes each UnicodeIdentifier check that it is in Normalization Form C, so that
+// identifiers that look alike are spelled alike. The parse package has no normalization
+// tables of its own, so the check is given, as in RequireNFC(norm.NFC.IsNormalString)
+// using golang.org/x/text/unicode/norm.
+func RequireNFC(isNormal func(string) bool) Option {
+	return func(state *State) {
+		state.IsNFC = isNormal
+	}
+}
+
+// notXIDStart and notXIDContinue are the characters of ID_Start and ID_Continue that are
+// left out of XID_Start and XID_Continue, which are closed under NFKC normalization.
+var notXIDStart = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x037A, Hi: 0x037A, Stride: 1},
+		{Lo: 0x0E33, Hi: 0x0EB3, Stride: 0x0EB3 - 0x0E33},
+		{Lo: 0x309B, Hi: 0x309C, Stride: 1},
+		{Lo: 0xFC5E, Hi: 0xFC63, Stride: 1},
+		{Lo: 0xFDFA, Hi: 0xFDFB, Stride: 1},
+		{Lo: 0xFE70, Hi: 0xFE7E, Stride: 2},
+		{Lo: 0xFF9E, Hi: 0xFF9F, Stride: 1},
+	},
+}
+
+var notXIDContinue = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x037A, Hi: 0x037A, Stride: 1},
+		{Lo: 0x309B, Hi: 0x309C, Stride: 1},
+		{Lo: 0xFC5E, Hi: 0xFC63, Stride: 1},
+		{Lo: 0xFDFA, Hi: 0xFDFB, Stride: 1},
+		{Lo: 0xFE70, Hi: 0xFE7E, Stride: 2},
+	},
+}
+
+// isIDStart reports whether a character has the ID_Start property, which is derived as
+// L + Nl + Other_ID_Start - Pattern_Syntax - Pattern_White_Space.
+func isIDStart(r rune) bool {
+	if r < utf8.RuneSelf {
+		return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z'
+	}
+	return unicode.In(r, unicode.L, unicode.Nl, unicode.Other_ID_Start) &&
+		!unicode.In(r, unicode.Pattern_Syntax, unicode.Pattern_White_Space)
+}
+
+// isIDContinue reports whether a character has the ID_Continue property, which is derived as
+// ID_Start + Mn + Mc + Nd + Pc + Other_ID_Continue - Pattern_Syntax - Pattern_White_Space.
+func isIDContinue(r rune) bool {
+	if r < utf8.RuneSelf {
+		return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '_'
+	}
+	return unicode.In(r, unicode.L, unicode.Nl, unicode.Other_ID_Start, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue) &&
+		!unicode.In(r, unicode.Pattern_Syntax, unicode.Pattern_White_Space)
+}
+
+func isXIDStart(r rune) bool {
+	return isIDStart(r) && !unicode.Is(notXIDStart, r)
+}
+
+func isXIDContinue(r rune) bool {
+	return isIDContinue(r) && !unicode.Is(notXIDContinue, r)
+}
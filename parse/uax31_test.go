@@ -0,0 +1,64 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+type uax31Binding struct {
+	Name   UnicodeIdentifier `extraStart:"$" extraContinue:"\\-"`
+	Equals Literal           `parse:"="`
+	Value  UnicodeIdentifier
+}
+
+func TestUnicodeIdentifier(t *testing.T) {
+	binding := uax31Binding{}
+	for _, each := range []struct{ source, name, value string }{
+		{"変数=値", "変数", "値"},
+		{"$my-name=имя_2", "$my-name", "имя_2"},
+		{"Δx=x\u0301", "Δx", "x\u0301"},
+	} {
+		if err := Parse(each.source, &binding); err != nil {
+			t.Errorf("%q: unexpected error: %s", each.source, err)
+			continue
+		}
+		if string(binding.Name.Contents) != each.name || string(binding.Value.Contents) != each.value {
+			t.Errorf("%q: expected %q and %q but got %q and %q", each.source, each.name, each.value, binding.Name.Contents, binding.Value.Contents)
+		}
+	}
+	for _, source := range []string{"1x=y", "x=-y", "x=ͺy", "x=·y"} {
+		if err := Parse(source, &binding); err == nil {
+			t.Errorf("%q: expected an error", source)
+		}
+	}
+
+	// A stand-in for norm.NFC.IsNormalString that rejects combining acute accents.
+	isNormal := func(name string) bool { return !strings.Contains(name, "\u0301") }
+	err := Parse("x=e\u0301", &binding, RequireNFC(isNormal))
+	if err == nil || err.Error() != "Expected identifier in Normalization Form C but found \"e\u0301\" at 1:3" {
+		t.Errorf("expected a normalization error but got %v", err)
+	}
+	if err := Parse("x=\u00e9", &binding, RequireNFC(isNormal)); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestGenerateUnicodeIdentifier(t *testing.T) {
+	generator := NewGenerator(1)
+	extra, nonASCII := false, false
+	for i := 0; i < 40; i++ {
+		text, err := generator.Generate(&uax31Binding{}, WithReserved("x"))
+		if err != nil {
+			t.Fatalf("error ``%s'' unexpected", err)
+		}
+		name := strings.SplitN(text, "=", 2)[0]
+		if name == "x" {
+			t.Errorf("expected reserved words not to be generated, got %q", text)
+		}
+		extra = extra || strings.ContainsAny(name, "$-")
+		nonASCII = nonASCII || strings.IndexFunc(text, func(r rune) bool { return r >= 0x80 }) >= 0
+	}
+	if !extra || !nonASCII {
+		t.Errorf("expected identifiers with extra characters and with non-ASCII characters")
+	}
+}
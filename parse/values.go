@@ -0,0 +1,153 @@
+package parse
+
+import (
+	"net/netip"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Duration is a duration such as 30s or 1h30m, as parsed by time.ParseDuration.
+type Duration struct {
+	Duration time.Duration
+	Span     Span
+}
+
+func (d *Duration) ParseInto(state *State, tag reflect.StructTag) error {
+	return longestToken(state, "duration", 64, isDurationRune, func(text string) (err error) {
+		d.Duration, err = time.ParseDuration(text)
+		return err
+	}, &d.Span)
+}
+
+// Time is a time parsed by time.Parse with the layout given by the tag layout:"<layout>".
+// The layout may also name one of the layouts of the time package, such as "DateOnly".
+// By default it is RFC3339, as in 2026-10-17T12:00:00Z.
+type Time struct {
+	Time time.Time
+	Span Span
+}
+
+// timeLayouts are the layouts that can be named in a layout tag.
+var timeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"DateTime":    "2006-01-02 15:04:05",
+	"DateOnly":    "2006-01-02",
+	"TimeOnly":    "15:04:05",
+}
+
+func timeLayout(tag reflect.StructTag) string {
+	layout := tag.Get("layout")
+	if layout == "" {
+		return time.RFC3339
+	}
+	if named, ok := timeLayouts[layout]; ok {
+		return named
+	}
+	return layout
+}
+
+func (t *Time) ParseInto(state *State, tag reflect.StructTag) error {
+	layout := timeLayout(tag)
+	// Month and day names and fractional seconds can make a time longer than its layout.
+	return longestToken(state, "time", len(layout)+32, func(r rune) bool { return r != '\n' }, func(text string) (err error) {
+		t.Time, err = time.Parse(layout, text)
+		return err
+	}, &t.Span)
+}
+
+// Addr is an IPv4 or IPv6 address, such as 10.0.0.1 or ::1.
+type Addr struct {
+	Addr netip.Addr
+	Span Span
+}
+
+func (a *Addr) ParseInto(state *State, tag reflect.StructTag) error {
+	return longestToken(state, "IP address", 64, isAddressRune, func(text string) (err error) {
+		a.Addr, err = netip.ParseAddr(text)
+		return err
+	}, &a.Span)
+}
+
+// Prefix is an IP network, such as 10.0.0.0/8.
+type Prefix struct {
+	Prefix netip.Prefix
+	Span   Span
+}
+
+func (p *Prefix) ParseInto(state *State, tag reflect.StructTag) error {
+	return longestToken(state, "IP prefix", 64, func(r rune) bool { return isAddressRune(r) || r == '/' }, func(text string) (err error) {
+		p.Prefix, err = netip.ParsePrefix(text)
+		return err
+	}, &p.Span)
+}
+
+// AddrPort is an IP address with a port, such as 10.0.0.1:80 or [::1]:8080.
+type AddrPort struct {
+	AddrPort netip.AddrPort
+	Span     Span
+}
+
+func (a *AddrPort) ParseInto(state *State, tag reflect.StructTag) error {
+	return longestToken(state, "IP address and port", 72, func(r rune) bool { return isAddressRune(r) || r == '[' || r == ']' }, func(text string) (err error) {
+		a.AddrPort, err = netip.ParseAddrPort(text)
+		return err
+	}, &a.Span)
+}
+
+// isDurationRune allows the characters of a duration, including both the micro sign µ
+// (U+00B5) and the Greek letter μ (U+03BC) that time.ParseDuration accepts for microseconds.
+func isDurationRune(r rune) bool {
+	return '0' <= r && r <= '9' || r == '.' || r == '+' || r == '-' || strings.ContainsRune("nsuµμmh", r)
+}
+
+func isAddressRune(r rune) bool {
+	// Zones, as in fe80::1%eth0, may contain letters and punctuation.
+	return r < utf8.RuneSelf && digitValue(byte(r)) < 36 || r == '.' || r == ':' || r == '%' || r == '_' || r == '-'
+}
+
+// longestToken finds the longest text at the current position, up to size bytes of characters
+// allowed by the given function, that parse accepts, and consumes it. If no such text exists,
+// the error for the longest candidate is reported at the current position.
+func longestToken(state *State, name string, size int, allowed func(rune) bool, parse func(string) error, span *Span) error {
+	rest := state.Rest()
+	end := 0
+	for end < len(rest) {
+		r, width := utf8.DecodeRune(rest[end:])
+		if end+width > size || !allowed(r) {
+			break
+		}
+		end += width
+	}
+	if end == 0 {
+		return state.Errorf("Expected %s", name)
+	}
+	var first error
+	for length := end; length > 0; length-- {
+		if length < end && !utf8.RuneStart(rest[length]) {
+			// Only whole characters are tried.
+			continue
+		}
+		err := parse(string(rest[:length]))
+		if err == nil {
+			*span = state.Advance(length)
+			return nil
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	return state.Errorf("Expected %s but %s", name, first.Error())
+}
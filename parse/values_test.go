@@ -0,0 +1,61 @@
+package parse
+
+import (
+	"errors"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+type valuesConfig struct {
+	Timeout  Duration
+	Space1   Literal `parse:" "`
+	Start    Time
+	Space2   Literal `parse:" "`
+	Day      Time    `layout:"DateOnly"`
+	Space3   Literal `parse:" "`
+	Network  Prefix
+	Space4   Literal `parse:" "`
+	Listen   AddrPort
+	Space5   Literal `parse:" "`
+	Resolver Addr
+	End      Literal `parse:";"`
+}
+
+func TestValues(t *testing.T) {
+	config := valuesConfig{}
+	source := "1h30s 2026-10-17T12:00:00Z 2026-10-18 10.0.0.0/8 [::1]:8080 fe80::1;"
+	if err := Parse(source, &config); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if config.Timeout.Duration != time.Hour+30*time.Second {
+		t.Errorf("unexpected duration %s", config.Timeout.Duration)
+	}
+	if !config.Start.Time.Equal(time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)) || config.Day.Time.Day() != 18 {
+		t.Errorf("unexpected times %s and %s", config.Start.Time, config.Day.Time)
+	}
+	if config.Network.Prefix != netip.MustParsePrefix("10.0.0.0/8") || config.Listen.AddrPort != netip.MustParseAddrPort("[::1]:8080") {
+		t.Errorf("unexpected network %s and address %s", config.Network.Prefix, config.Listen.AddrPort)
+	}
+	if config.Resolver.Addr != netip.MustParseAddr("fe80::1") || string(config.Resolver.Span.Text()) != "fe80::1" {
+		t.Errorf("unexpected resolver %s", config.Resolver.Addr)
+	}
+
+	err := Parse("30 2026-10-17T12:00:00Z", &config)
+	if err == nil || err.Error() != `Expected duration but time: missing unit in duration "30" at 1:1` {
+		t.Errorf("expected a duration error but got %v", err)
+	}
+	err = Parse("1s 2026-13-01T00:00:00Z", &config)
+	var parseError *Error
+	if !errors.As(err, &parseError) || !strings.HasPrefix(parseError.Message, "Expected time but") || parseError.Position.Column != 4 {
+		t.Errorf("expected a time error but got %v", err)
+	}
+
+	for _, text := range []string{"5\u00B5s", "5\u03BCs"} {
+		var duration Duration
+		if err := Parse(text, &duration); err != nil || duration.Duration != 5*time.Microsecond || duration.Span.Len() != len(text) {
+			t.Errorf("expected %q to be 5 microseconds, got %s (%v)", text, duration.Duration, err)
+		}
+	}
+}
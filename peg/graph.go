@@ -0,0 +1,193 @@
+package peg
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/Nathan-Fenner/Reflect-Peg/parse"
+)
+
+// The graph types are shared with package parse, which marks cycles and renders graphs.
+type (
+	EdgeKind = parse.EdgeKind
+	// For interface rules, an Edge's Field names the alternate type instead of a field.
+	Edge = parse.Edge
+	// Nodes are the struct, interface and FromParse types used as rules.
+	Graph = parse.Graph
+)
+
+const (
+	EdgeSequence  = parse.EdgeSequence
+	EdgeChoice    = parse.EdgeChoice
+	EdgeOptional  = parse.EdgeOptional
+	EdgeMany      = parse.EdgeMany
+	EdgeLookahead = parse.EdgeLookahead
+	// EdgeFrom leads from a FromParse type to the type it is parsed from.
+	EdgeFrom EdgeKind = "from"
+)
+
+var byteParserType = reflect.TypeOf((*ByteParser)(nil)).Elem()
+
+// GraphOf builds the graph of the grammar rooted at the type pointed to by target.
+// Interface alternations are taken from the context, as in ParseInto.
+func GraphOf(target interface{}, context Context) *Graph {
+	pointer := reflect.TypeOf(target)
+	if pointer == nil || pointer.Kind() != reflect.Ptr {
+		panic(fmt.Sprintf("GraphOf is called with non-pointer %+v", pointer))
+	}
+	alternates := newInternalContext(context).Alternates
+	g := &Graph{}
+	g.Root, _ = ruleOf(pointer.Elem())
+	if g.Root == nil {
+		return g
+	}
+	seen := map[reflect.Type]bool{g.Root: true}
+	queue := []reflect.Type{g.Root}
+	add := func(edge Edge) {
+		to, kinds := ruleOf(edge.To)
+		if to == nil {
+			return
+		}
+		edge.To = to
+		edge.Kinds = append(edge.Kinds, kinds...)
+		g.Edges = append(g.Edges, edge)
+		if !seen[to] {
+			seen[to] = true
+			queue = append(queue, to)
+		}
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		g.Nodes = append(g.Nodes, node)
+		if from, ok := fromType(node); ok {
+			add(Edge{From: node, To: from, Field: "FromParse", Kinds: []EdgeKind{EdgeFrom}})
+			continue
+		}
+		switch node.Kind() {
+		case reflect.Struct:
+			for i := 0; i < node.NumField(); i++ {
+				add(Edge{From: node, To: node.Field(i).Type, Field: node.Field(i).Name, Kinds: []EdgeKind{EdgeSequence}})
+			}
+		case reflect.Interface:
+			options, ok := alternates[node]
+			if !ok {
+				panic(fmt.Sprintf("unable to graph interface %+v with no alternates provided (%+v)", node, alternates))
+			}
+			for _, option := range options {
+				add(Edge{From: node, To: option, Field: option.Name(), Kinds: []EdgeKind{EdgeChoice}})
+			}
+		}
+	}
+	markLeft(g, alternates)
+	g.MarkCycles()
+	return g
+}
+
+// ruleOf strips optional, many and lookahead wrappers from a type until it reaches a rule.
+// Leaves (ByteParsers) produce nil.
+func ruleOf(into reflect.Type) (reflect.Type, []EdgeKind) {
+	kinds := []EdgeKind{}
+	for {
+		if reflect.PtrTo(into).Implements(byteParserType) {
+			return nil, nil
+		}
+		if _, ok := fromType(into); ok {
+			return into, kinds
+		}
+		switch into.Kind() {
+		case reflect.Struct, reflect.Interface:
+			return into, kinds
+		case reflect.Ptr:
+			kinds = append(kinds, EdgeOptional)
+		case reflect.Slice:
+			kinds = append(kinds, EdgeMany)
+		case reflect.Chan:
+			kinds = append(kinds, EdgeLookahead)
+		default:
+			return nil, nil
+		}
+		into = into.Elem()
+	}
+}
+
+// fromType finds the type that a FromParser is parsed from, following the rules in parseIntoField.
+func fromType(into reflect.Type) (reflect.Type, bool) {
+	if into.Kind() == reflect.Interface || into.Kind() == reflect.Ptr {
+		return nil, false
+	}
+	method, ok := reflect.PtrTo(into).MethodByName("FromParse")
+	if !ok {
+		return nil, false
+	}
+	kind := method.Func.Type()
+	if kind.NumIn() == 4 && kind.In(2) == reflect.TypeOf(Location{}) && kind.In(3) == reflect.TypeOf([]byte{}) && kind.NumOut() == 1 && kind.Out(0) == reflect.TypeOf((*error)(nil)).Elem() {
+		return kind.In(1), true
+	}
+	return nil, false
+}
+
+// nullable reports whether a value of the given type can be parsed without consuming input.
+// The assumed map holds the current guess for each rule.
+func nullable(into reflect.Type, assumed map[reflect.Type]bool) bool {
+	if reflect.PtrTo(into).Implements(byteParserType) {
+		return false
+	}
+	if _, ok := fromType(into); ok {
+		return assumed[into]
+	}
+	switch into.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Chan:
+		return true
+	case reflect.Struct, reflect.Interface:
+		return assumed[into]
+	}
+	return false
+}
+
+// markLeft computes which rules are nullable, and from that which edges are in a leftmost position.
+func markLeft(g *Graph, alternates map[reflect.Type][]reflect.Type) {
+	assumed := map[reflect.Type]bool{}
+	for changed := true; changed; {
+		changed = false
+		for _, node := range g.Nodes {
+			if assumed[node] {
+				continue
+			}
+			result := false
+			if from, ok := fromType(node); ok {
+				result = nullable(from, assumed)
+			} else if node.Kind() == reflect.Interface {
+				for _, option := range alternates[node] {
+					result = result || nullable(option, assumed)
+				}
+			} else {
+				result = true
+				for i := 0; i < node.NumField(); i++ {
+					result = result && nullable(node.Field(i).Type, assumed)
+				}
+			}
+			if result {
+				assumed[node] = true
+				changed = true
+			}
+		}
+	}
+	for i := range g.Edges {
+		edge := &g.Edges[i]
+		edge.Left = true
+		if edge.Kinds[0] != EdgeSequence {
+			continue
+		}
+		for f := 0; f < edge.From.NumField(); f++ {
+			field := edge.From.Field(f)
+			if field.Name == edge.Field {
+				break
+			}
+			if !nullable(field.Type, assumed) {
+				edge.Left = false
+				break
+			}
+		}
+	}
+}
@@ -3,6 +3,10 @@ package peg
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/Nathan-Fenner/Reflect-Peg/parse"
 )
 
 // TODO: add check for accidentally-embedded structs with tag parameters.
@@ -13,9 +17,16 @@ import (
 // TODO: allow commits
 
 // A Location represents a place in the source.
+// Offset counts bytes from the start of the source, starting at 0.
+// Line and Column start at 1. Columns count in the unit and tab width chosen by the
+// Context, which are bytes and 4 columns by default.
 type Location struct {
+	Offset int
 	Line   int
 	Column int
+	// columns and tabWidth are those of the Context, so that Advance counts the same way.
+	columns  parse.ColumnUnit
+	tabWidth int
 }
 
 // String converts the location into a readable string.
@@ -23,10 +34,52 @@ func (l Location) String() string {
 	return fmt.Sprintf("%d:%d", l.Line, l.Column)
 }
 
+// Advance finds the location just after the text, which starts at this location.
+func (l Location) Advance(text []byte) Location {
+	tabWidth := l.tabWidth
+	if tabWidth <= 0 {
+		tabWidth = 4
+	}
+	for i := 0; i < len(text); {
+		r, size := rune(text[i]), 1
+		if l.columns != parse.ColumnBytes {
+			r, size = utf8.DecodeRune(text[i:])
+		}
+		switch {
+		case r == '\n':
+			l.Line++
+			l.Column = 1
+		case r == '\r':
+			l.Column = 1
+		case r == '\t':
+			l.Column = (l.Column-1)/tabWidth*tabWidth + tabWidth + 1
+		case l.columns == parse.ColumnUTF16 && r >= 0x10000:
+			// Characters outside the basic multilingual plane take a surrogate pair.
+			l.Column += 2
+		default:
+			l.Column++
+		}
+		i += size
+	}
+	l.Offset += len(text)
+	return l
+}
+
+// A Span is the range of source from Start up to (but not including) End.
+type Span struct {
+	Start Location
+	End   Location
+}
+
+// String converts the span into a readable string.
+func (s Span) String() string {
+	return fmt.Sprintf("%s-%s", s.Start, s.End)
+}
+
 // A Literal expects a tag to indicate its allowable value(s).
 type Literal struct {
 	Source []byte
-	At     Location
+	Span   Span
 }
 
 // ByteParse is used by the peg package to directly extract from the input.
@@ -37,16 +90,16 @@ func (literal *Literal) ByteParse(source []byte, here Location, tagFull reflect.
 	}
 	if len(source) < len(tag) {
 		// TODO: handle whitespace
-		return 0, fmt.Errorf("expected `%s` but got `%s` (end of input)", tag, source)
+		return 0, fmt.Errorf("%s: expected `%s` but got `%s` (end of input)", here, tag, source)
 	}
 	for i := range tag {
 		if source[i] != tag[i] {
 			// TODO: handle whitespace
-			return 0, fmt.Errorf("expected `%s` but got `%s...`", tag, source[:len(tag)])
+			return 0, fmt.Errorf("%s: expected `%s` but got `%s...`", here, tag, source[:len(tag)])
 		}
 	}
 	literal.Source = source[:len(tag)]
-	literal.At = here
+	literal.Span = Span{here, here.Advance(literal.Source)}
 	return len(tag), nil
 }
 
@@ -76,14 +129,36 @@ type AlternateMap map[interface{}][]interface{}
 // Interface alternations are provided through it.
 type Context struct {
 	Alternates AlternateMap
+	// Columns chooses what the columns of locations count, as parse.WithColumns does.
+	// By default, they count bytes.
+	Columns parse.ColumnUnit
+	// TabWidth is the distance between tab stops, 4 if not set.
+	TabWidth int
 }
 
 // ParseInto takes a pointer to a value and parses the source provided into it,
 // using the shape of the type.
 func ParseInto(target interface{}, source []byte, context Context) error {
+	newContext := newInternalContext(context)
+	newContext.Source = source
+	newContext.Lines = []int{0}
+	for i, b := range source {
+		if b == '\n' {
+			newContext.Lines = append(newContext.Lines, i+1)
+		}
+	}
+	_, err := parseIntoField(reflect.ValueOf(target), source, reflect.StructTag(""), newContext)
+	return err
+}
+
+// newInternalContext checks the alternates provided and converts them into types.
+func newInternalContext(context Context) internalContext {
 	newContext := internalContext{
 		Alternates: map[reflect.Type][]reflect.Type{},
 		Parsed:     map[parseTarget]parseResult{},
+		Columns:    context.Columns,
+		TabWidth:   context.TabWidth,
+		located:    &Location{},
 	}
 	for kind, options := range context.Alternates {
 		if reflect.TypeOf(kind).Kind() != reflect.Ptr {
@@ -105,8 +180,7 @@ func ParseInto(target interface{}, source []byte, context Context) error {
 		}
 		newContext.Alternates[interfaceType] = newOptions
 	}
-	_, err := parseIntoField(reflect.ValueOf(target), source, Location{}, reflect.StructTag(""), newContext)
-	return err
+	return newContext
 }
 
 type parseTarget struct {
@@ -125,12 +199,32 @@ type parseResult struct {
 type internalContext struct {
 	Alternates map[reflect.Type][]reflect.Type
 	Parsed     map[parseTarget]parseResult
+	Source     []byte
+	// Lines holds the offset at which each line of Source starts.
+	Lines    []int
+	Columns  parse.ColumnUnit
+	TabWidth int
+	// located is the last location found, which later locations on the same line are
+	// counted on from.
+	located *Location
+}
+
+// locate finds the location of the rest of the source.
+func (context internalContext) locate(rest []byte) Location {
+	offset := len(context.Source) - len(rest)
+	line := sort.Search(len(context.Lines), func(i int) bool { return context.Lines[i] > offset }) - 1
+	start := Location{Offset: context.Lines[line], Line: line + 1, Column: 1, columns: context.Columns, tabWidth: context.TabWidth}
+	if last := *context.located; last.Line == start.Line && start.Offset <= last.Offset && last.Offset <= offset {
+		start = last
+	}
+	*context.located = start.Advance(context.Source[start.Offset:offset])
+	return *context.located
 }
 
 // parseIntoField expects a pointer to a value of parseable type.
 // If parsing succeeds, then the returned error will be nil and the target will be assigned to the
 // parsed value.
-func parseIntoField(target reflect.Value, source []byte, here Location, tag reflect.StructTag, context internalContext) (resultRest []byte, resultErr error) {
+func parseIntoField(target reflect.Value, source []byte, tag reflect.StructTag, context internalContext) (resultRest []byte, resultErr error) {
 	if target.Type().Kind() != reflect.Ptr {
 		panic(fmt.Sprintf("ParseInto is called with non-pointer %+v", target))
 	}
@@ -160,7 +254,7 @@ func parseIntoField(target reflect.Value, source []byte, here Location, tag refl
 		}
 	}()
 	if byteParser, ok := target.Interface().(ByteParser); ok {
-		n, err := byteParser.ByteParse(source, here, tag)
+		n, err := byteParser.ByteParse(source, context.locate(source), tag)
 		if err != nil {
 			return nil, err
 		}
@@ -172,13 +266,13 @@ func parseIntoField(target reflect.Value, source []byte, here Location, tag refl
 			if method.Func.Type().NumIn() == 4 && method.Func.Type().In(2) == reflect.TypeOf(Location{}) && method.Func.Type().In(3) == reflect.TypeOf([]byte{}) && method.Func.Type().NumOut() == 1 && method.Func.Type().Out(0) == reflect.TypeOf((*error)(nil)).Elem() {
 				// Construct an object of the 'from' type, and parse into it.
 				fromPointer := reflect.New(method.Func.Type().In(1))
-				rest, err := parseIntoField(fromPointer, source, here, reflect.StructTag(""), context)
+				rest, err := parseIntoField(fromPointer, source, reflect.StructTag(""), context)
 				if err != nil {
 					return nil, err
 				}
 				// Use the parsed object as a parameter to the FromParse method on the target.
 				if err := method.Func.Call([]reflect.Value{target,
-					fromPointer.Elem(), reflect.ValueOf(here), reflect.ValueOf(tag)})[0].Interface(); err != nil {
+					fromPointer.Elem(), reflect.ValueOf(context.locate(source)), reflect.ValueOf(tag)})[0].Interface(); err != nil {
 					return nil, err.(error)
 				}
 				return rest, nil
@@ -187,10 +281,9 @@ func parseIntoField(target reflect.Value, source []byte, here Location, tag refl
 	}
 	if target.Type().Elem().Kind() == reflect.Slice {
 		collected := reflect.MakeSlice(target.Type().Elem(), 0, 0)
-		there := here
 		for {
 			eachPointer := reflect.New(target.Type().Elem().Elem())
-			rest, err := parseIntoField(eachPointer, source, there, tag, context)
+			rest, err := parseIntoField(eachPointer, source, tag, context)
 			if err != nil {
 				break
 			}
@@ -202,7 +295,7 @@ func parseIntoField(target reflect.Value, source []byte, here Location, tag refl
 	}
 	if target.Type().Elem().Kind() == reflect.Ptr {
 		optionalPointer := reflect.New(target.Type().Elem().Elem())
-		rest, err := parseIntoField(optionalPointer, source, here, tag, context)
+		rest, err := parseIntoField(optionalPointer, source, tag, context)
 		if err == nil {
 			optional := reflect.New(target.Type().Elem())
 			optional.Elem().Set(optionalPointer.Elem())
@@ -213,7 +306,7 @@ func parseIntoField(target reflect.Value, source []byte, here Location, tag refl
 	}
 	if target.Type().Elem().Kind() == reflect.Chan {
 		forwardPointer := reflect.New(target.Type().Elem().Elem())
-		_, err := parseIntoField(forwardPointer, source, here, tag, context)
+		_, err := parseIntoField(forwardPointer, source, tag, context)
 		if err == nil {
 			forward := reflect.MakeChan(target.Type().Elem(), 1)
 			forward.Send(forwardPointer.Elem())
@@ -225,7 +318,7 @@ func parseIntoField(target reflect.Value, source []byte, here Location, tag refl
 		result := reflect.New(target.Type().Elem())
 		for i := 0; i < target.Type().Elem().NumField(); i++ {
 			tag := target.Type().Elem().Field(i).Tag
-			rest, err := parseIntoField(result.Elem().Field(i).Addr(), source, here, tag, context)
+			rest, err := parseIntoField(result.Elem().Field(i).Addr(), source, tag, context)
 			if err != nil {
 				return nil, err
 			}
@@ -242,13 +335,13 @@ func parseIntoField(target reflect.Value, source []byte, here Location, tag refl
 		}
 		for _, option := range options {
 			var optionTarget = reflect.New(option)
-			rest, err := parseIntoField(optionTarget, source, here, tag, context)
+			rest, err := parseIntoField(optionTarget, source, tag, context)
 			if err == nil {
 				target.Elem().Set(reflect.ValueOf(optionTarget.Elem().Interface()))
 				return rest, nil
 			}
 		}
-		return nil, fmt.Errorf("%s: can't parse %+v", here.String(), target.Type().Elem())
+		return nil, fmt.Errorf("%s: can't parse %+v", context.locate(source), target.Type().Elem())
 	}
 	panic(fmt.Sprintf("unsupported parse type %+v of kind %s", target.Type().Elem(), target.Type().Elem().Kind().String()))
 }
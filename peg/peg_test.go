@@ -1,6 +1,11 @@
 package peg
 
-import "testing"
+import (
+	"strings"
+	"testing"
+
+	"github.com/Nathan-Fenner/Reflect-Peg/parse"
+)
 
 func TestPass(t *testing.T) {
 	type ExampleAB struct {
@@ -82,3 +87,98 @@ func TestAlternation(t *testing.T) {
 		t.Errorf("error ``%s'' unexpected", err.Error())
 	}
 }
+
+func TestGraph(t *testing.T) {
+	type Expression interface {
+	}
+
+	type Number struct {
+		Digit Literal `parse:"1"`
+	}
+	type Sum struct {
+		Left  Expression
+		Plus  Literal `parse:"+"`
+		Right Number
+	}
+
+	graph := GraphOf(new(Expression), Context{
+		Alternates: AlternateMap{
+			new(Expression): {new(Sum), new(Number)},
+		},
+	})
+	if len(graph.Nodes) != 3 {
+		t.Errorf("expected 3 rules but got %+v", graph.Nodes)
+	}
+	leftRecursive := 0
+	for _, edge := range graph.Edges {
+		if edge.LeftRecursive {
+			leftRecursive++
+		}
+	}
+	if leftRecursive != 2 {
+		t.Errorf("expected Expression -> Sum -> Expression to be left-recursive: %+v", graph.Edges)
+	}
+	if !strings.Contains(graph.Dot(), "color=red") {
+		t.Errorf("expected left-recursion to be highlighted in:\n%s", graph.Dot())
+	}
+}
+
+func TestLocation(t *testing.T) {
+	type Line struct {
+		A       Literal `parse:"A"`
+		Newline Literal `parse:"\n"`
+	}
+	type Example struct {
+		Lines []Line
+		B     Literal `parse:"B"`
+	}
+
+	var example Example
+	err := ParseInto(&example, []byte("A\nA\nB"), Context{})
+	if err != nil {
+		t.Fatalf("error ``%s'' unexpected", err)
+	}
+	if at := example.Lines[1].A.Span.Start; at != (Location{Offset: 2, Line: 2, Column: 1}) {
+		t.Errorf("unexpected location %+v", at)
+	}
+	if span := example.B.Span; span.Start != (Location{Offset: 4, Line: 3, Column: 1}) || span.End != (Location{Offset: 5, Line: 3, Column: 2}) {
+		t.Errorf("unexpected span %+v", span)
+	}
+}
+
+func TestLocationColumns(t *testing.T) {
+	type Example struct {
+		A Literal `parse:"\t😀"`
+		B Literal `parse:"é"`
+	}
+	cases := []struct {
+		context Context
+		want    string
+	}{
+		{Context{}, "1:9-1:11"},
+		{Context{Columns: parse.ColumnRunes, TabWidth: 8}, "1:10-1:11"},
+		{Context{Columns: parse.ColumnUTF16, TabWidth: 8}, "1:11-1:12"},
+	}
+	for _, each := range cases {
+		var example Example
+		if err := ParseInto(&example, []byte("\t😀é"), each.context); err != nil {
+			t.Fatalf("error ``%s'' unexpected", err)
+		}
+		if span := example.B.Span.String(); span != each.want {
+			t.Errorf("%+v: expected span %s but got %s", each.context, each.want, span)
+		}
+	}
+}
+
+func TestLocationBacktracking(t *testing.T) {
+	// Locations are found out of order when alternatives backtrack along a line.
+	context := newInternalContext(Context{})
+	context.Source = []byte("ab\tcd\nef")
+	context.Lines = []int{0, 6}
+	for _, offset := range []int{4, 1, 7, 3, 5, 0, 8} {
+		want := Location{Line: 1, Column: 1}.Advance(context.Source[:offset])
+		if got := context.locate(context.Source[offset:]); got != want {
+			t.Errorf("offset %d: expected %s but got %s", offset, want, got)
+		}
+	}
+}